@@ -0,0 +1,10 @@
+package asset
+
+type Asset struct {
+	Id               string            `json:"Id"`
+	Name             string            `json:"Name"`
+	Description      string            `json:"Description"`
+	AssetTypeId      string            `json:"AssetTypeId"`
+	Metadata         []Metadata        `json:"Metadata"`
+	StreamReferences []StreamReference `json:"StreamReferences"`
+}
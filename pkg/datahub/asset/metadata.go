@@ -0,0 +1,10 @@
+package asset
+
+type Metadata struct {
+	Id          string      `json:"Id"`
+	Name        string      `json:"Name"`
+	Description string      `json:"Description"`
+	SdsTypeCode string      `json:"SdsTypeCode"`
+	Value       interface{} `json:"Value"`
+	Uom         string      `json:"Uom"`
+}
@@ -0,0 +1,129 @@
+package datahub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/osisoft/sample-adh-grafana_backend_plugin-datasource/pkg/datahub/asset"
+)
+
+func AssetsQuery(ctx context.Context, d *DataHubClient, namespaceId string, token string, query string) (*data.Frame, error) {
+	basePath := d.resource + "/api/" + d.apiVersion + "/tenants/" + url.QueryEscape(d.tenantId) + "/namespaces/" + url.QueryEscape(namespaceId)
+	path := (basePath + "/assets?query=" + url.QueryEscape(query))
+
+	body, err := SdsRequest(ctx, d, token, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var assets []asset.Asset
+
+	err = json.Unmarshal(body, &assets)
+	if err != nil {
+		logger.Warn("Error parsing json", err.Error())
+		logger.Warn(fmt.Sprint(string(body)))
+		return nil, err
+	}
+
+	// create a dataframe
+	frame := data.NewFrame("response")
+
+	// create property lists from assets list
+	ids := make([]string, len(assets))
+	names := make([]string, len(assets))
+	descriptions := make([]string, len(assets))
+	metadata := make([]json.RawMessage, len(assets))
+	for i := 0; i < len(assets); i++ {
+		ids[i] = assets[i].Id
+		names[i] = assets[i].Name
+		descriptions[i] = assets[i].Description
+		metadata[i], err = getAssetMetadataJson(assets[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// add fields
+	frame.Fields = append(frame.Fields,
+		data.NewField("Id", nil, ids),
+		data.NewField("Name", nil, names),
+		data.NewField("Description", nil, descriptions),
+		data.NewField("Metadata", nil, metadata),
+	)
+
+	return frame, nil
+}
+
+// Reads the data of the asset's stream references, with at most concurrency requests in flight. References
+// that fail are reported in a notice rather than failing the query, unless every reference fails.
+func AssetsDataQuery(ctx context.Context, d *DataHubClient, namespaceId string, token string, id string, startIndex string, endIndex string, concurrency int) ([]*data.Frame, error) {
+	basePath := d.resource + "/api/" + d.apiVersion + "/tenants/" + url.QueryEscape(d.tenantId) + "/namespaces/" + url.QueryEscape(namespaceId)
+	path := (basePath + "/assets/" + url.QueryEscape(id))
+
+	body, err := SdsRequest(ctx, d, token, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var selectedAsset asset.Asset
+
+	err = json.Unmarshal(body, &selectedAsset)
+	if err != nil {
+		logger.Warn("Error parsing json", err.Error())
+		logger.Warn(fmt.Sprint(string(body)))
+		return nil, err
+	}
+
+	// read each referenced stream into its own frame, named after the asset and reference
+	references := selectedAsset.StreamReferences
+	results, errs := fanOut(len(references), concurrency, func(i int) (*data.Frame, error) {
+		return StreamsDataQuery(ctx, d, namespaceId, token, references[i].StreamId, startIndex, endIndex)
+	})
+
+	frames := make([]*data.Frame, 0, len(references))
+	var notices []data.Notice
+	for i, reference := range references {
+		referenceName := reference.Name
+		if referenceName == "" {
+			referenceName = reference.Id
+		}
+
+		if errs[i] != nil {
+			logger.Warn("Error reading stream reference", errs[i].Error())
+			notices = append(notices, data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("Stream reference %s could not be read: %s", referenceName, errs[i].Error()),
+			})
+			continue
+		}
+
+		frame := results[i]
+		frame.Name = selectedAsset.Name + "." + referenceName
+		frames = append(frames, frame)
+	}
+	if len(frames) == 0 && len(errs) > 0 {
+		return nil, errs[0]
+	}
+	if len(notices) > 0 {
+		frames[0].AppendNotices(notices...)
+	}
+
+	return frames, nil
+}
+
+// Flattens asset metadata into a JSON object of metadata names and values.
+func getAssetMetadataJson(a asset.Asset) (json.RawMessage, error) {
+	metadata := make(map[string]interface{}, len(a.Metadata))
+	for _, m := range a.Metadata {
+		name := m.Name
+		if name == "" {
+			name = m.Id
+		}
+		metadata[name] = m.Value
+	}
+
+	return json.Marshal(metadata)
+}
@@ -0,0 +1,178 @@
+package datahub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func TestAssetsQuery(t *testing.T) {
+	tests := []Tests{
+		{
+			name: "assets-query",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`[
+					{
+						"Id": "AssetId1",
+						"Name": "Pump 12",
+						"Description": "Feed pump",
+						"Metadata": [
+							{ "Id": "MetadataId1", "Name": "Site", "SdsTypeCode": "String", "Value": "Plant 1" }
+						],
+						"StreamReferences": []
+					}
+				]`))
+			})),
+			response: data.NewFrame("response",
+				data.NewField("Id", nil, []string{"AssetId1"}),
+				data.NewField("Name", nil, []string{"Pump 12"}),
+				data.NewField("Description", nil, []string{"Feed pump"}),
+				data.NewField("Metadata", nil, []json.RawMessage{json.RawMessage(`{"Site":"Plant 1"}`)}),
+			),
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer test.server.Close()
+
+			client := NewDataHubClient(test.server.URL, apiVersion, tenantId, "", "")
+			resp, err := AssetsQuery(context.Background(), &client, namespaceId, "token", "")
+
+			if !reflect.DeepEqual(resp, test.response) {
+				t.Errorf("FAILED: expected %v, got %v\n", test.response, resp)
+			}
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Expected error FAILED: expected %v, got %v\n", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestAssetsDataQuery(t *testing.T) {
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	mux := newStreamsTestMux(basePath)
+
+	mux.HandleFunc(basePath+"/assets/AssetId1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`
+			{
+				"Id": "AssetId1",
+				"Name": "Pump 12",
+				"Metadata": [],
+				"StreamReferences": [
+					{ "Id": "ReferenceId1", "Name": "Temperature", "StreamId": "StreamId1" }
+				]
+			}`))
+	})
+
+	mux.HandleFunc(basePath+"/streams/StreamId1/Data", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[
+			{
+				"Timestamp": "2022-06-04T00:00:00Z",
+				"Value": 20.5
+			}
+		]`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	expected := []*data.Frame{
+		data.NewFrame("Pump 12.Temperature",
+			data.NewField("Timestamp", nil, []time.Time{time.Date(2022, 6, 4, 0, 0, 0, 0, time.UTC)}),
+			data.NewField("Value", nil, []float64{20.5}),
+		),
+	}
+
+	client := NewDataHubClient(server.URL, apiVersion, tenantId, "", "")
+	resp, err := AssetsDataQuery(context.Background(), &client, namespaceId, "token", "AssetId1", "", "", 2)
+
+	if !reflect.DeepEqual(resp, expected) {
+		t.Errorf("FAILED: expected %v, got %v\n", expected, resp)
+	}
+	if err != nil {
+		t.Errorf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+}
+
+func TestAssetsDataQueryMissingReference(t *testing.T) {
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	mux := newStreamsTestMux(basePath)
+
+	// StreamId2 is not served, so reading it returns 404
+	mux.HandleFunc(basePath+"/assets/AssetId1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`
+			{
+				"Id": "AssetId1",
+				"Name": "Pump 12",
+				"Metadata": [],
+				"StreamReferences": [
+					{ "Id": "ReferenceId1", "Name": "Temperature", "StreamId": "StreamId1" },
+					{ "Id": "ReferenceId2", "Name": "Pressure", "StreamId": "StreamId2" }
+				]
+			}`))
+	})
+
+	mux.HandleFunc(basePath+"/assets/AssetId2", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`
+			{
+				"Id": "AssetId2",
+				"Name": "Pump 13",
+				"Metadata": [],
+				"StreamReferences": [
+					{ "Id": "ReferenceId2", "Name": "Pressure", "StreamId": "StreamId2" }
+				]
+			}`))
+	})
+
+	mux.HandleFunc(basePath+"/streams/StreamId1/Data", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[
+			{
+				"Timestamp": "2022-06-04T00:00:00Z",
+				"Value": 20.5
+			}
+		]`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewDataHubClient(server.URL, apiVersion, tenantId, "", "")
+
+	t.Run("one-reference-missing", func(t *testing.T) {
+		resp, err := AssetsDataQuery(context.Background(), &client, namespaceId, "token", "AssetId1", "", "", 2)
+
+		if err != nil {
+			t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+		}
+		if len(resp) != 1 || resp[0].Name != "Pump 12.Temperature" {
+			t.Fatalf("FAILED: expected the Temperature frame only, got %v\n", resp)
+		}
+		if resp[0].Meta == nil || len(resp[0].Meta.Notices) != 1 || !strings.HasPrefix(resp[0].Meta.Notices[0].Text, "Stream reference Pressure could not be read") {
+			t.Errorf("FAILED: expected a notice for the Pressure reference, got %v\n", resp[0].Meta)
+		}
+	})
+
+	t.Run("every-reference-missing", func(t *testing.T) {
+		resp, err := AssetsDataQuery(context.Background(), &client, namespaceId, "token", "AssetId2", "", "", 2)
+
+		if err == nil {
+			t.Errorf("Expected error FAILED: expected an error, got %v\n", resp)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package datahub
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Returned, wrapped, when a community stream id is not a stream URL on the configured Data Hub host.
+var ErrInvalidStreamSelf = errors.New("invalid community stream")
+
+var streamSelfPathPattern = regexp.MustCompile(`^/api/[^/]+/tenants/[^/]+/namespaces/[^/]+/streams/[^/]+$`)
+
+// Checks that a community stream's Self URL points at a stream on the configured resource before the
+// token is sent to it. Community queries take the URL from the query, so it must not be trusted as is.
+func validateCommunityStreamSelf(d *DataHubClient, self string) error {
+	resource, err := url.Parse(d.resource)
+	if err != nil {
+		return fmt.Errorf("%w: unable to parse resource: %v", ErrInvalidStreamSelf, err)
+	}
+
+	u, err := url.Parse(self)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidStreamSelf, err)
+	}
+
+	if !strings.EqualFold(u.Scheme, resource.Scheme) || !strings.EqualFold(u.Host, resource.Host) {
+		return fmt.Errorf("%w: %s is not on %s", ErrInvalidStreamSelf, u.Redacted(), d.resource)
+	}
+	if u.User != nil || u.RawQuery != "" || u.ForceQuery || u.Fragment != "" || u.Opaque != "" {
+		return fmt.Errorf("%w: %s must not have credentials, a query or a fragment", ErrInvalidStreamSelf, u.Redacted())
+	}
+
+	// compare the escaped path, so escaped slashes cannot add or hide segments
+	path := strings.TrimPrefix(u.EscapedPath(), strings.TrimSuffix(resource.EscapedPath(), "/"))
+	if !streamSelfPathPattern.MatchString(path) {
+		return fmt.Errorf("%w: %s is not a stream URL", ErrInvalidStreamSelf, u.Redacted())
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if unescaped, err := url.PathUnescape(segment); err != nil || unescaped == "." || unescaped == ".." {
+			return fmt.Errorf("%w: %s is not a stream URL", ErrInvalidStreamSelf, u.Redacted())
+		}
+	}
+
+	return nil
+}
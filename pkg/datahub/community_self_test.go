@@ -0,0 +1,64 @@
+package datahub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidateCommunityStreamSelf(t *testing.T) {
+	type selfTests struct {
+		name          string
+		self          string
+		expectedError error
+	}
+
+	client := NewDataHubClient("https://uswe.datahub.connect.aveva.com", apiVersion, tenantId, "", "")
+	streamPath := "/api/" + apiVersion + "/tenants/tenant1/namespaces/namespace1/streams/"
+
+	tests := []selfTests{
+		{name: "stream", self: "https://uswe.datahub.connect.aveva.com" + streamPath + "StreamId1", expectedError: nil},
+		{name: "escaped-stream-id", self: "https://uswe.datahub.connect.aveva.com" + streamPath + "Stream%20Id%231", expectedError: nil},
+		{name: "host-case", self: "https://USWE.datahub.connect.aveva.com" + streamPath + "StreamId1", expectedError: nil},
+		{name: "other-host", self: "https://attacker.example.com" + streamPath + "StreamId1", expectedError: ErrInvalidStreamSelf},
+		{name: "host-prefix", self: "https://uswe.datahub.connect.aveva.com.example.com" + streamPath + "StreamId1", expectedError: ErrInvalidStreamSelf},
+		{name: "other-scheme", self: "http://uswe.datahub.connect.aveva.com" + streamPath + "StreamId1", expectedError: ErrInvalidStreamSelf},
+		{name: "credentials", self: "https://user@uswe.datahub.connect.aveva.com" + streamPath + "StreamId1", expectedError: ErrInvalidStreamSelf},
+		{name: "query", self: "https://uswe.datahub.connect.aveva.com" + streamPath + "StreamId1?redirect=1", expectedError: ErrInvalidStreamSelf},
+		{name: "not-a-stream", self: "https://uswe.datahub.connect.aveva.com/api/" + apiVersion + "/tenants/tenant1/namespaces/namespace1", expectedError: ErrInvalidStreamSelf},
+		{name: "escaped-slash", self: "https://uswe.datahub.connect.aveva.com" + streamPath + "StreamId1%2FData", expectedError: nil},
+		{name: "dot-segment", self: "https://uswe.datahub.connect.aveva.com/api/" + apiVersion + "/tenants/tenant1/namespaces/namespace1/streams/..", expectedError: ErrInvalidStreamSelf},
+		{name: "relative", self: streamPath + "StreamId1", expectedError: ErrInvalidStreamSelf},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateCommunityStreamSelf(&client, test.self)
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Expected error FAILED: expected %v, got %v\n", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestCommunityStreamsDataQueryRejectsOtherHost(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewDataHubClient("https://uswe.datahub.connect.aveva.com", apiVersion, tenantId, "", "")
+	self := server.URL + "/api/" + apiVersion + "/tenants/tenant1/namespaces/namespace1/streams/StreamId1"
+	_, err := CommunityStreamsDataQuery(context.Background(), &client, communityId, "token", self, "", "")
+
+	if !errors.Is(err, ErrInvalidStreamSelf) {
+		t.Errorf("Expected error FAILED: expected %v, got %v\n", ErrInvalidStreamSelf, err)
+	}
+	if requested {
+		t.Errorf("FAILED: expected no request to %v\n", server.URL)
+	}
+}
@@ -0,0 +1,172 @@
+package datahub
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/osisoft/sample-adh-grafana_backend_plugin-datasource/pkg/datahub/sds"
+)
+
+// Default time an unused stream stays in the data cache.
+const defaultDataCacheTtl = 10 * time.Minute
+
+// Caches the events of recent raw data queries per stream, so that a dashboard refreshing a sliding
+// window only reads the events added since its previous refresh. Streams that were not queried for the
+// TTL expire and the least recently used streams are evicted once the cache is full. Refreshes only
+// read events from the last cached one onwards, so events written earlier in the window are missed
+// until the stream is read in full again, which happens once its events are older than the TTL. A nil
+// cache caches nothing.
+type dataCache struct {
+	entries *lruCache
+	maxAge  time.Duration
+}
+
+// The events of one stream between start and end, in index order, read in full at read.
+type dataCacheEntry struct {
+	key     string
+	start   time.Time
+	end     time.Time
+	read    time.Time
+	events  []map[string]interface{}
+	indexes []time.Time
+}
+
+func newDataCache(ttl time.Duration, size int) *dataCache {
+	return &dataCache{entries: newLruCache(ttl, size), maxAge: ttl}
+}
+
+func (c *dataCache) get(key string) (*dataCacheEntry, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	entry, ok := c.entries.get(key)
+	if !ok {
+		return nil, false
+	}
+	return entry.(*dataCacheEntry), true
+}
+
+// Stores the entry, replacing any previous entry of the stream. Entries are never modified once
+// stored, so readers may use them without holding the lock.
+func (c *dataCache) set(entry *dataCacheEntry) {
+	if c == nil {
+		return
+	}
+	c.entries.set(entry.key, entry)
+}
+
+func (c *dataCache) remove(key string) {
+	if c == nil {
+		return
+	}
+	c.entries.remove(key)
+}
+
+func (c *dataCache) clear() {
+	if c == nil {
+		return
+	}
+	c.entries.clear()
+}
+
+// Reads the events of a stream between startIndex and endIndex from dataPath, the stream's Data
+// endpoint. When the cache holds an earlier window of the stream that this one extends, only the events
+// from the last cached event onwards are requested and events before the new start are dropped. Entries
+// read in full longer ago than the cache's maximum age are read in full again. The delta request is always made with the caller's token, so cached events are only returned to callers
+// that may still read the stream. Streams not indexed by time, and truncated reads, are not cached.
+func getCachedPagedSdsData(ctx context.Context, d *DataHubClient, token string, dataPath string, headers map[string]string, sdsType sds.SdsType, startIndex string, endIndex string) ([]map[string]interface{}, bool, error) {
+	keyId, isTimeIndexed := getTimeKeyProperty(sdsType)
+	start, startErr := time.Parse(time.RFC3339, startIndex)
+	end, endErr := time.Parse(time.RFC3339, endIndex)
+	if d.data == nil || !isTimeIndexed || startErr != nil || endErr != nil || end.Before(start) {
+		return getPagedSdsData(ctx, d, token, getDataWindowPath(dataPath, startIndex, endIndex), headers)
+	}
+
+	key := dataPath + "\n" + headers[communityIdHeader]
+	if cached, ok := d.data.get(key); ok && time.Since(cached.read) < d.data.maxAge && !start.Before(cached.start) && !start.After(cached.end) && !end.Before(cached.end) {
+		// re-read from the last cached event, which may have been updated since
+		deltaStart := cached.end
+		if len(cached.indexes) > 0 {
+			deltaStart = cached.indexes[len(cached.indexes)-1]
+		}
+
+		delta, truncated, err := getPagedSdsData(ctx, d, token, getDataWindowPath(dataPath, deltaStart.Format(time.RFC3339Nano), endIndex), headers)
+		if err != nil {
+			return nil, false, err
+		}
+		deltaIndexes, ok := getEventIndexes(delta, keyId)
+		if !truncated && ok {
+			entry := &dataCacheEntry{key: key, start: start, end: end, read: cached.read}
+			for i := range cached.events {
+				if !cached.indexes[i].Before(start) && cached.indexes[i].Before(deltaStart) {
+					entry.events = append(entry.events, cached.events[i])
+					entry.indexes = append(entry.indexes, cached.indexes[i])
+				}
+			}
+			entry.events = append(entry.events, delta...)
+			entry.indexes = append(entry.indexes, deltaIndexes...)
+
+			// the merged events are limited like a full read, which is not cached either
+			if len(entry.events) > d.maxEvents {
+				d.data.remove(key)
+				return entry.events[:d.maxEvents], true, nil
+			}
+			d.data.set(entry)
+			return entry.events, false, nil
+		}
+	}
+
+	read := time.Now()
+	sdsData, truncated, err := getPagedSdsData(ctx, d, token, getDataWindowPath(dataPath, startIndex, endIndex), headers)
+	if err != nil {
+		return nil, false, err
+	}
+
+	indexes, ok := getEventIndexes(sdsData, keyId)
+	if truncated || !ok {
+		d.data.remove(key)
+	} else {
+		d.data.set(&dataCacheEntry{key: key, start: start, end: end, read: read, events: sdsData, indexes: indexes})
+	}
+	return sdsData, truncated, nil
+}
+
+func getDataWindowPath(dataPath string, startIndex string, endIndex string) string {
+	return dataPath + "?startIndex=" + url.QueryEscape(startIndex) + "&endIndex=" + url.QueryEscape(endIndex)
+}
+
+// Finds the key property of a type indexed by time alone. Types with compound indexes are not supported.
+func getTimeKeyProperty(sdsType sds.SdsType) (string, bool) {
+	keyId := ""
+	keys := 0
+	for _, property := range sdsType.Properties {
+		if !property.IsKey {
+			continue
+		}
+		keys++
+		switch property.SdsType.SdsTypeCode {
+		case "DateTime", "DateTimeOffset":
+			keyId = property.Id
+		}
+	}
+	return keyId, keys == 1 && keyId != ""
+}
+
+// Parses the index of every event, failing if an index is missing or not a time.
+func getEventIndexes(sdsData []map[string]interface{}, keyId string) ([]time.Time, bool) {
+	indexes := make([]time.Time, len(sdsData))
+	for i, event := range sdsData {
+		value, ok := event[keyId].(string)
+		if !ok {
+			return nil, false
+		}
+		index, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, false
+		}
+		indexes[i] = index
+	}
+	return indexes, true
+}
@@ -0,0 +1,168 @@
+package datahub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osisoft/sample-adh-grafana_backend_plugin-datasource/pkg/datahub/sds"
+)
+
+// Serves one event per hour of the day starting at origin, with the hour as value, recording the start
+// index of the last data request.
+func newHourlyDataMux(basePath string, origin time.Time, requestedStartIndex *string) *http.ServeMux {
+	var mu sync.Mutex
+	mux := newStreamsTestMux(basePath)
+	mux.HandleFunc(basePath+"/streams/StreamId1/Data", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		*requestedStartIndex = r.URL.Query().Get("startIndex")
+		mu.Unlock()
+
+		start, _ := time.Parse(time.RFC3339Nano, r.URL.Query().Get("startIndex"))
+		end, _ := time.Parse(time.RFC3339Nano, r.URL.Query().Get("endIndex"))
+		events := []map[string]interface{}{}
+		for hour := 0; hour < 24; hour++ {
+			index := origin.Add(time.Duration(hour) * time.Hour)
+			if !index.Before(start) && !index.After(end) {
+				events = append(events, map[string]interface{}{"Timestamp": index.Format(time.RFC3339), "Value": hour})
+			}
+		}
+		body, _ := json.Marshal(events)
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	})
+	return mux
+}
+
+func TestStreamsDataQueryIncrementalCache(t *testing.T) {
+	type windowTests struct {
+		name               string
+		startIndex         string
+		endIndex           string
+		expectedStartIndex string
+		expectedValues     []float64
+	}
+
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	origin := time.Date(2022, 6, 4, 0, 0, 0, 0, time.UTC)
+
+	var requestedStartIndex string
+	mux := newHourlyDataMux(basePath, origin, &requestedStartIndex)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewDataHubClient(server.URL, apiVersion, tenantId, "", "")
+	client.SetDataCache(0, 10)
+	defer client.Close()
+
+	tests := []windowTests{
+		{name: "first-window", startIndex: "2022-06-04T00:00:00Z", endIndex: "2022-06-04T03:00:00Z", expectedStartIndex: "2022-06-04T00:00:00Z", expectedValues: []float64{0, 1, 2, 3}},
+		{name: "sliding-window", startIndex: "2022-06-04T01:30:00Z", endIndex: "2022-06-04T05:30:00Z", expectedStartIndex: "2022-06-04T03:00:00Z", expectedValues: []float64{2, 3, 4, 5}},
+		{name: "same-window", startIndex: "2022-06-04T01:30:00Z", endIndex: "2022-06-04T05:30:00Z", expectedStartIndex: "2022-06-04T05:00:00Z", expectedValues: []float64{2, 3, 4, 5}},
+		{name: "earlier-window", startIndex: "2022-06-04T00:00:00Z", endIndex: "2022-06-04T01:00:00Z", expectedStartIndex: "2022-06-04T00:00:00Z", expectedValues: []float64{0, 1}},
+		{name: "disjoint-window", startIndex: "2022-06-04T10:00:00Z", endIndex: "2022-06-04T11:00:00Z", expectedStartIndex: "2022-06-04T10:00:00Z", expectedValues: []float64{10, 11}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			frame, err := StreamsDataQuery(context.Background(), &client, namespaceId, "token", "StreamId1", test.startIndex, test.endIndex)
+			if err != nil {
+				t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+			}
+
+			if requestedStartIndex != test.expectedStartIndex {
+				t.Errorf("FAILED: expected request from %v, got %v\n", test.expectedStartIndex, requestedStartIndex)
+			}
+
+			values := make([]float64, frame.Rows())
+			for i := 0; i < frame.Rows(); i++ {
+				values[i] = frame.Fields[1].At(i).(float64)
+			}
+			if !reflect.DeepEqual(values, test.expectedValues) {
+				t.Errorf("FAILED: expected %v, got %v\n", test.expectedValues, values)
+			}
+		})
+	}
+}
+
+func TestStreamsDataQueryCacheLimits(t *testing.T) {
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	origin := time.Date(2022, 6, 4, 0, 0, 0, 0, time.UTC)
+
+	var requestedStartIndex string
+	server := httptest.NewServer(newHourlyDataMux(basePath, origin, &requestedStartIndex))
+	defer server.Close()
+
+	t.Run("max-age", func(t *testing.T) {
+		client := NewDataHubClient(server.URL, apiVersion, tenantId, "", "")
+		client.SetDataCache(0, 10)
+		client.data.maxAge = 50 * time.Millisecond
+		defer client.Close()
+
+		for _, endIndex := range []string{"2022-06-04T03:00:00Z", "2022-06-04T05:00:00Z"} {
+			time.Sleep(60 * time.Millisecond)
+			_, err := StreamsDataQuery(context.Background(), &client, namespaceId, "token", "StreamId1", "2022-06-04T00:00:00Z", endIndex)
+			if err != nil {
+				t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+			}
+			if requestedStartIndex != "2022-06-04T00:00:00Z" {
+				t.Errorf("FAILED: expected request from %v, got %v\n", "2022-06-04T00:00:00Z", requestedStartIndex)
+			}
+		}
+	})
+
+	t.Run("max-events", func(t *testing.T) {
+		client := NewDataHubClient(server.URL, apiVersion, tenantId, "", "")
+		client.SetDataCache(0, 10)
+		client.SetPaging(0, 5)
+		defer client.Close()
+
+		_, err := StreamsDataQuery(context.Background(), &client, namespaceId, "token", "StreamId1", "2022-06-04T00:00:00Z", "2022-06-04T03:00:00Z")
+		if err != nil {
+			t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+		}
+
+		frame, err := StreamsDataQuery(context.Background(), &client, namespaceId, "token", "StreamId1", "2022-06-04T00:00:00Z", "2022-06-04T05:00:00Z")
+		if err != nil {
+			t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+		}
+		if requestedStartIndex != "2022-06-04T03:00:00Z" {
+			t.Errorf("FAILED: expected request from %v, got %v\n", "2022-06-04T03:00:00Z", requestedStartIndex)
+		}
+		if frame.Rows() != 5 || frame.Meta == nil || len(frame.Meta.Notices) != 1 {
+			t.Errorf("FAILED: expected %v rows with a truncation notice, got %v\n", 5, frame)
+		}
+	})
+}
+
+func TestGetTimeKeyProperty(t *testing.T) {
+	property := func(id string, isKey bool, typeCode sds.SdsTypeCode) sds.SdsTypeProperty {
+		return sds.SdsTypeProperty{Id: id, IsKey: isKey, SdsType: sds.SdsType{SdsTypeCode: typeCode}}
+	}
+
+	tests := []struct {
+		name          string
+		properties    []sds.SdsTypeProperty
+		expectedKeyId string
+		expectedOk    bool
+	}{
+		{name: "date-time", properties: []sds.SdsTypeProperty{property("Timestamp", true, "DateTime"), property("Value", false, "Double")}, expectedKeyId: "Timestamp", expectedOk: true},
+		{name: "date-time-offset", properties: []sds.SdsTypeProperty{property("Value", false, "Double"), property("Time", true, "DateTimeOffset")}, expectedKeyId: "Time", expectedOk: true},
+		{name: "integer-key", properties: []sds.SdsTypeProperty{property("Depth", true, "Int32"), property("Value", false, "Double")}, expectedOk: false},
+		{name: "compound-key", properties: []sds.SdsTypeProperty{property("Timestamp", true, "DateTime"), property("Depth", true, "Int32")}, expectedOk: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keyId, ok := getTimeKeyProperty(sds.SdsType{Properties: test.properties})
+			if ok != test.expectedOk || (ok && keyId != test.expectedKeyId) {
+				t.Errorf("FAILED: expected %v %v, got %v %v\n", test.expectedKeyId, test.expectedOk, keyId, ok)
+			}
+		})
+	}
+}
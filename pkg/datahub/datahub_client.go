@@ -1,31 +1,49 @@
 package datahub
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/osisoft/sample-adh-grafana_backend_plugin-datasource/pkg/datahub/community"
 	"github.com/osisoft/sample-adh-grafana_backend_plugin-datasource/pkg/datahub/sds"
 )
 
 type DataHubClient struct {
-	resource        string
-	apiVersion      string
-	tenantId        string
-	clientId        string
-	clientSecret    string
-	token           string
-	tokenExpiration int64
-	client          *http.Client
+	resource       string
+	apiVersion     string
+	tenantId       string
+	clientId       string
+	clientSecret   string
+	tokens         *tokenManager
+	client         *http.Client
+	retryPolicy    RetryPolicy
+	requestTimeout time.Duration
+	metadata       *metadataCache
+	data           *dataCache
+	requests       *requestGroup
+	pageSize       int
+	maxEvents      int
 }
 
+// Default number of events requested per page and the default upper limit of events read per query.
+const (
+	defaultPageSize  = 250000
+	defaultMaxEvents = 1000000
+)
+
 func NewDataHubClient(resource string, apiVersion string, tenantId string, clientId string, clientSecret string) DataHubClient {
 	return DataHubClient{
 		resource:     resource,
@@ -33,99 +51,270 @@ func NewDataHubClient(resource string, apiVersion string, tenantId string, clien
 		tenantId:     tenantId,
 		clientId:     clientId,
 		clientSecret: clientSecret,
+		tokens:       newTokenManager(),
 		client:       &http.Client{},
+		retryPolicy:  DefaultRetryPolicy(),
+		metadata:     newMetadataCache(defaultMetadataCacheTtl, defaultMetadataCacheSize),
+		requests:     newRequestGroup(),
+		pageSize:     defaultPageSize,
+		maxEvents:    defaultMaxEvents,
+	}
+}
+
+// Sets how many events are requested per page and the upper limit of events read by a single data query.
+// Non-positive values keep the defaults.
+func (d *DataHubClient) SetPaging(pageSize int, maxEvents int) {
+	if pageSize > 0 {
+		d.pageSize = pageSize
+	}
+	if maxEvents > 0 {
+		d.maxEvents = maxEvents
+	}
+}
+
+// Sets how requests failing with a transient status are retried. Non-positive values keep the defaults.
+func (d *DataHubClient) SetRetryPolicy(policy RetryPolicy) {
+	if policy.MaxAttempts > 0 {
+		d.retryPolicy.MaxAttempts = policy.MaxAttempts
+	}
+	if policy.InitialBackoff > 0 {
+		d.retryPolicy.InitialBackoff = policy.InitialBackoff
 	}
+	if policy.MaxBackoff > 0 {
+		d.retryPolicy.MaxBackoff = policy.MaxBackoff
+	}
+}
+
+// Replaces the HTTP client, for example with one built by NewHttpClient.
+func (d *DataHubClient) SetHttpClient(client *http.Client) {
+	d.client = client
 }
 
-func GetClientToken(d *DataHubClient) (string, error) {
-	if (d.tokenExpiration - time.Now().Unix()) > (5 * 60) {
-		return ("Bearer " + d.token), nil
+// Sets how long a single HTTP request may take. Zero leaves requests bounded only by their context.
+func (d *DataHubClient) SetRequestTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		d.requestTimeout = timeout
+	}
+}
+
+// Sets how long stream and type metadata is cached and how many entries are kept. Zero values keep
+// the defaults, and a negative TTL disables the cache.
+func (d *DataHubClient) SetMetadataCache(ttl time.Duration, size int) {
+	if ttl == 0 && size <= 0 {
+		return
+	}
+	if ttl < 0 {
+		d.metadata = nil
+		return
+	}
+	if ttl == 0 {
+		ttl = defaultMetadataCacheTtl
+	}
+	if size <= 0 {
+		size = defaultMetadataCacheSize
+	}
+	d.metadata = newMetadataCache(ttl, size)
+}
+
+// Enables caching the events of raw data queries for up to size streams, each kept for ttl after it was
+// last queried. A zero TTL uses the default, and a non-positive size disables the cache.
+func (d *DataHubClient) SetDataCache(ttl time.Duration, size int) {
+	if size <= 0 {
+		d.data = nil
+		return
+	}
+	if ttl <= 0 {
+		ttl = defaultDataCacheTtl
+	}
+	d.data = newDataCache(ttl, size)
+}
+
+// Releases the client's background work, such as proactive token refreshes, and drops cached metadata and data.
+func (d *DataHubClient) Close() {
+	d.tokens.close()
+	d.metadata.clear()
+	d.data.clear()
+}
+
+func GetClientToken(ctx context.Context, d *DataHubClient) (string, error) {
+	return d.tokens.get(ctx, d)
+}
+
+// Requests a new access token using the client credentials flow, returning the token and its lifetime in seconds.
+func requestClientToken(ctx context.Context, d *DataHubClient) (string, int64, error) {
+	if d.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.requestTimeout)
+		defer cancel()
 	}
 
 	wellKnownEndpoint := d.resource + "/identity/.well-known/openid-configuration"
-	req, err := http.NewRequest("GET", wellKnownEndpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", wellKnownEndpoint, nil)
 	if err != nil {
-		log.DefaultLogger.Warn("Error forming request", err.Error())
-		return "", err
+		logger.Warn("Error forming request", err.Error())
+		return "", 0, err
 	}
 
 	resp, err := d.client.Do(req)
 	if err != nil {
-		log.DefaultLogger.Warn("Error requesting well known endpoints", err.Error())
-		return "", err
+		logger.Warn("Error requesting well known endpoints", err.Error())
+		return "", 0, err
 	}
 	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		log.DefaultLogger.Warn("Error reading response", err.Error())
-		return "", err
+		logger.Warn("Error reading response", err.Error())
+		return "", 0, err
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		err = fmt.Errorf("Status: " + resp.Status + "\nBody: " + string(body))
-		log.DefaultLogger.Warn("Error making request", err)
-		return "", err
+		logger.Warn("Error making request", err)
+		return "", 0, err
 	}
 
 	var openIdConfig map[string]interface{}
 
 	err = json.Unmarshal(body, &openIdConfig)
 	if err != nil {
-		log.DefaultLogger.Warn("Error parsing json", err.Error())
-		return "", err
+		logger.Warn("Error parsing json", err.Error())
+		return "", 0, err
 	}
 
-	tokenEndpoint := openIdConfig["token_endpoint"].(string)
+	tokenEndpoint, ok := openIdConfig["token_endpoint"].(string)
+	if !ok {
+		return "", 0, fmt.Errorf("OpenID configuration does not contain a token endpoint")
+	}
 
-	resp, err = d.client.PostForm(tokenEndpoint,
-		url.Values{
-			"client_id":     {d.clientId},
-			"client_secret": {d.clientSecret},
-			"grant_type":    {"client_credentials"}})
+	form := url.Values{
+		"client_id":     {d.clientId},
+		"client_secret": {d.clientSecret},
+		"grant_type":    {"client_credentials"}}
+	req, err = http.NewRequestWithContext(ctx, "POST", tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		logger.Warn("Error forming request", err.Error())
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
+	resp, err = d.client.Do(req)
 	if err != nil {
-		log.DefaultLogger.Warn("Error requesting token", err.Error())
-		return "", err
+		logger.Warn("Error requesting token", err.Error())
+		return "", 0, err
 	}
 
 	defer resp.Body.Close()
 
 	body, err = ioutil.ReadAll(resp.Body)
 	if err != nil {
-		log.DefaultLogger.Warn("Error requesting token", err.Error())
-		return "", err
+		logger.Warn("Error requesting token", err.Error())
+		return "", 0, err
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		err = fmt.Errorf("Status: " + resp.Status + "\nBody: " + string(body))
-		log.DefaultLogger.Warn("Error making request", err)
-		return "", err
+		logger.Warn("Error making request", err)
+		return "", 0, err
 	}
 
 	var tokenInformation map[string]interface{}
 
 	err = json.Unmarshal(body, &tokenInformation)
 	if err != nil {
-		log.DefaultLogger.Warn("Error parsing json", err.Error())
-		return "", err
+		logger.Warn("Error parsing json", err.Error())
+		return "", 0, err
+	}
+
+	accessToken, ok := tokenInformation["access_token"].(string)
+	if !ok {
+		return "", 0, fmt.Errorf("Token response does not contain an access token")
 	}
+	expiresIn, ok := tokenInformation["expires_in"].(float64)
+	if !ok {
+		return "", 0, fmt.Errorf("Token response does not contain an expiration")
+	}
+
+	return accessToken, int64(expiresIn), nil
+}
+
+func SdsRequest(ctx context.Context, d *DataHubClient, token string, path string, headers map[string]string) ([]byte, error) {
+	body, _, err := sdsRequestWithHeaders(ctx, d, token, path, headers)
+	return body, err
+}
+
+// Makes an SDS request and also returns the response headers, for reads that page through Link headers.
+// Identical requests already in flight, made with the same token and headers, share their response.
+func sdsRequestWithHeaders(ctx context.Context, d *DataHubClient, token string, path string, headers map[string]string) ([]byte, http.Header, error) {
+	return d.requests.do(ctx, getRequestKey(token, path, headers), func(ctx context.Context) ([]byte, http.Header, error) {
+		return sdsRequestWithRefresh(ctx, d, token, path, headers)
+	})
+}
+
+// Makes an SDS request. When a client credentials token is rejected, the token is refreshed and the
+// request replayed once.
+func sdsRequestWithRefresh(ctx context.Context, d *DataHubClient, token string, path string, headers map[string]string) ([]byte, http.Header, error) {
+	body, respHeaders, err := doSdsRequestWithRetry(ctx, d, token, path, headers)
+
+	var sdsErr *SdsError
+	if errors.As(err, &sdsErr) && sdsErr.StatusCode == http.StatusUnauthorized && d.tokens.issued(token) {
+		logger.Info("Token rejected, refreshing token and retrying request")
+		d.tokens.invalidate(token)
+		token, err = GetClientToken(ctx, d)
+		if err != nil {
+			return nil, nil, err
+		}
+		return doSdsRequestWithRetry(ctx, d, token, path, headers)
+	}
+
+	return body, respHeaders, err
+}
+
+// Makes an SDS request, retrying transient failures according to the client's retry policy.
+func doSdsRequestWithRetry(ctx context.Context, d *DataHubClient, token string, path string, headers map[string]string) ([]byte, http.Header, error) {
+	for attempt := 1; ; attempt++ {
+		body, respHeaders, err := doSdsRequest(ctx, d, token, path, headers)
+
+		var sdsErr *SdsError
+		if !errors.As(err, &sdsErr) || !isRetryableStatus(sdsErr.StatusCode) {
+			return body, respHeaders, err
+		}
 
-	d.token = tokenInformation["access_token"].(string)
-	d.tokenExpiration = int64(tokenInformation["expires_in"].(float64)) + time.Now().Unix()
+		delay, ok := d.retryPolicy.backoff(attempt, sdsErr.RetryAfter)
+		if attempt >= d.retryPolicy.MaxAttempts || !ok {
+			if isThrottledStatus(sdsErr.StatusCode) {
+				return nil, nil, fmt.Errorf("%w: %s after %d attempts", ErrThrottled, sdsErr.Status, attempt)
+			}
+			return nil, nil, err
+		}
 
-	return ("Bearer " + d.token), nil
+		logger.Info("Retrying request", "status", sdsErr.Status, "attempt", attempt, "delay", delay.String())
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
 }
 
-func SdsRequest(d *DataHubClient, token string, path string, headers map[string]string) ([]byte, error) {
-	log.DefaultLogger.Debug("Making query to", path)
+func doSdsRequest(ctx context.Context, d *DataHubClient, token string, path string, headers map[string]string) ([]byte, http.Header, error) {
+	logger.Debug("Making query to", path)
+
+	if d.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.requestTimeout)
+		defer cancel()
+	}
 
 	// request data or collection items
-	req, err := http.NewRequest("GET", path, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", path, nil)
 	if err != nil {
-		log.DefaultLogger.Warn("Error forming request", err.Error())
-		return nil, err
+		logger.Warn("Error forming request", err.Error())
+		return nil, nil, err
 	}
 
-	req.Header.Add("Authorization", token)
+	// Edge Data Store requests are not authenticated
+	if token != "" {
+		req.Header.Add("Authorization", token)
+	}
 
 	// add optional headers
 	for k, v := range headers {
@@ -134,40 +323,54 @@ func SdsRequest(d *DataHubClient, token string, path string, headers map[string]
 
 	resp, err := d.client.Do(req)
 	if err != nil {
-		log.DefaultLogger.Warn("Error making request", err.Error())
-		return nil, err
+		logger.Warn("Error making request", err.Error())
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		log.DefaultLogger.Warn("Error reading request body", err.Error())
-		return nil, err
+		logger.Warn("Error reading request body", err.Error())
+		return nil, nil, err
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		err = fmt.Errorf("Status: " + resp.Status + "\nBody: " + string(body))
-		log.DefaultLogger.Warn("Error making request", err)
-		return nil, err
+		err = &SdsError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body), RetryAfter: resp.Header.Get("Retry-After")}
+		logger.Warn("Error making request", err)
+		return nil, nil, err
 	}
 
-	return body, nil
+	return body, resp.Header, nil
 }
 
-func StreamsQuery(d *DataHubClient, namespaceId string, token string, query string) (*data.Frame, error) {
-	basePath := d.resource + "/api/" + d.apiVersion + "/tenants/" + url.QueryEscape(d.tenantId) + "/namespaces/" + url.QueryEscape(namespaceId)
-	path := (basePath + "/streams?query=" + url.QueryEscape(query))
-
-	body, err := SdsRequest(d, token, path, nil)
+func StreamsQuery(ctx context.Context, d *DataHubClient, namespaceId string, token string, query string) (*data.Frame, error) {
+	streams, err := searchStreams(ctx, d, namespaceId, token, query, 0)
 	if err != nil {
 		return nil, err
 	}
 
-	var streams []sds.SdsStream
+	// create a dataframe
+	frame := data.NewFrame("response")
 
-	err = json.Unmarshal(body, &streams)
+	// create property lists from streams list
+	ids := make([]string, len(streams))
+	names := make([]string, len(streams))
+	for i := 0; i < len(streams); i++ {
+		ids[i] = streams[i].Id
+		names[i] = streams[i].Name
+	}
+
+	// add fields
+	frame.Fields = append(frame.Fields,
+		data.NewField("Id", nil, ids),
+		data.NewField("Name", nil, names),
+	)
+
+	return frame, nil
+}
+
+func CommunityStreamsQuery(ctx context.Context, d *DataHubClient, communityId string, token string, query string) (*data.Frame, error) {
+	streams, err := searchCommunityStreams(ctx, d, communityId, token, query, 0)
 	if err != nil {
-		log.DefaultLogger.Warn("Error parsing json", err.Error())
-		log.DefaultLogger.Warn(fmt.Sprint(string(body)))
 		return nil, err
 	}
 
@@ -178,7 +381,7 @@ func StreamsQuery(d *DataHubClient, namespaceId string, token string, query stri
 	ids := make([]string, len(streams))
 	names := make([]string, len(streams))
 	for i := 0; i < len(streams); i++ {
-		ids[i] = streams[i].Id
+		ids[i] = getCommunityStreamSelf(d, streams[i])
 		names[i] = streams[i].Name
 	}
 
@@ -191,12 +394,41 @@ func StreamsQuery(d *DataHubClient, namespaceId string, token string, query stri
 	return frame, nil
 }
 
-func CommunityStreamsQuery(d *DataHubClient, communityId string, token string, query string) (*data.Frame, error) {
+// Searches the namespace's streams. A count of zero leaves the number of results to the SDS default.
+func searchStreams(ctx context.Context, d *DataHubClient, namespaceId string, token string, query string, count int) ([]sds.SdsStream, error) {
+	basePath := d.resource + "/api/" + d.apiVersion + "/tenants/" + url.QueryEscape(d.tenantId) + "/namespaces/" + url.QueryEscape(namespaceId)
+	path := (basePath + "/streams?query=" + url.QueryEscape(query))
+	if count > 0 {
+		path += "&count=" + strconv.Itoa(count)
+	}
+
+	body, err := SdsRequest(ctx, d, token, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var streams []sds.SdsStream
+
+	err = json.Unmarshal(body, &streams)
+	if err != nil {
+		logger.Warn("Error parsing json", err.Error())
+		logger.Warn(fmt.Sprint(string(body)))
+		return nil, err
+	}
+
+	return streams, nil
+}
+
+// Searches the community's streams. A count of zero leaves the number of results to the SDS default.
+func searchCommunityStreams(ctx context.Context, d *DataHubClient, communityId string, token string, query string, count int) ([]community.StreamSearchResult, error) {
 	basePath := d.resource + "/api/" + d.apiVersion + "/search/communities/" + url.QueryEscape(communityId)
 
 	path := (basePath + "/streams?query=" + url.QueryEscape(query))
+	if count > 0 {
+		path += "&count=" + strconv.Itoa(count)
+	}
 
-	body, err := SdsRequest(d, token, path, nil)
+	body, err := SdsRequest(ctx, d, token, path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -205,139 +437,533 @@ func CommunityStreamsQuery(d *DataHubClient, communityId string, token string, q
 
 	err = json.Unmarshal(body, &streams)
 	if err != nil {
-		log.DefaultLogger.Warn("Error parsing json", err.Error())
-		log.DefaultLogger.Warn(fmt.Sprint(string(body)))
+		logger.Warn("Error parsing json", err.Error())
+		logger.Warn(fmt.Sprint(string(body)))
 		return nil, err
 	}
 
-	// create a dataframe
-	frame := data.NewFrame("response")
+	return streams, nil
+}
 
-	// create property lists from streams list
-	ids := make([]string, len(streams))
-	names := make([]string, len(streams))
-	for i := 0; i < len(streams); i++ {
-		// replace api version for compatibility with preview route
-		// this can be removed once community features are released
-		ids[i] = strings.Replace(streams[i].Self, "/v1/", "/"+d.apiVersion+"/", 1)
-		names[i] = streams[i].Name
+func getCommunityStreamSelf(d *DataHubClient, stream community.StreamSearchResult) string {
+	// replace api version for compatibility with preview route
+	// this can be removed once community features are released
+	return strings.Replace(stream.Self, "/v1/", "/"+d.apiVersion+"/", 1)
+}
+
+func StreamsDataQuery(ctx context.Context, d *DataHubClient, namespaceId string, token string, id string, startIndex string, endIndex string) (*data.Frame, error) {
+	basePath := d.resource + "/api/" + d.apiVersion + "/tenants/" + url.QueryEscape(d.tenantId) + "/namespaces/" + url.QueryEscape(namespaceId)
+
+	stream, sdsType, err := getStreamAndType(ctx, d, basePath, token, id)
+	if err != nil {
+		return nil, err
 	}
 
-	// add fields
-	frame.Fields = append(frame.Fields,
-		data.NewField("Id", nil, ids),
-		data.NewField("Name", nil, names),
-	)
+	// get data
+	path := (basePath + "/streams/" + url.QueryEscape(id) + "/Data")
+	sdsData, truncated, err := getCachedPagedSdsData(ctx, d, token, path, nil, sdsType, startIndex, endIndex)
+	if err != nil {
+		return nil, err
+	}
 
-	return frame, nil
+	return createPagedDataFrameFromSdsData(ctx, d, stream.Name, sdsType, sdsData, truncated)
 }
 
-func StreamsDataQuery(d *DataHubClient, namespaceId string, token string, id string, startIndex string, endIndex string) (*data.Frame, error) {
+func StreamsStreamViewDataQuery(ctx context.Context, d *DataHubClient, namespaceId string, token string, id string, streamViewId string, startIndex string, endIndex string) (*data.Frame, error) {
 	basePath := d.resource + "/api/" + d.apiVersion + "/tenants/" + url.QueryEscape(d.tenantId) + "/namespaces/" + url.QueryEscape(namespaceId)
 
-	// get type Id
-	path := (basePath + "/streams/" + url.QueryEscape(id))
-	body, err := SdsRequest(d, token, path, nil)
+	stream, sdsType, err := getStreamAndStreamViewType(ctx, d, basePath, token, id, streamViewId)
 	if err != nil {
 		return nil, err
 	}
 
-	var stream sds.SdsStream
-	err = json.Unmarshal(body, &stream)
+	// get data transformed by the stream view
+	path := (basePath + "/streams/" + url.QueryEscape(id) + "/Data/Transform?startIndex=" + url.QueryEscape(startIndex) + "&endIndex=" + url.QueryEscape(endIndex) + "&streamViewId=" + url.QueryEscape(streamViewId))
+	sdsData, truncated, err := getPagedSdsData(ctx, d, token, path, nil)
 	if err != nil {
-		log.DefaultLogger.Warn("Error parsing json", err.Error())
-		log.DefaultLogger.Warn(fmt.Sprint(string(body)))
 		return nil, err
 	}
 
-	// get type info
-	path = (basePath + "/types/" + url.QueryEscape(stream.TypeId))
-	body, err = SdsRequest(d, token, path, nil)
+	return createPagedDataFrameFromSdsData(ctx, d, stream.Name, sdsType, sdsData, truncated)
+}
+
+func StreamsInterpolatedDataQuery(ctx context.Context, d *DataHubClient, namespaceId string, token string, id string, startIndex string, endIndex string, count int) (*data.Frame, error) {
+	basePath := d.resource + "/api/" + d.apiVersion + "/tenants/" + url.QueryEscape(d.tenantId) + "/namespaces/" + url.QueryEscape(namespaceId)
+
+	stream, sdsType, err := getStreamAndType(ctx, d, basePath, token, id)
 	if err != nil {
 		return nil, err
 	}
 
-	var sdsType sds.SdsType
-	err = json.Unmarshal(body, &sdsType)
+	// get interpolated data
+	path := (basePath + "/streams/" + url.QueryEscape(id) + "/Data/Interpolated?startIndex=" + url.QueryEscape(startIndex) + "&endIndex=" + url.QueryEscape(endIndex) + "&count=" + strconv.Itoa(count))
+	sdsData, err := getSdsData(ctx, d, token, path, nil)
 	if err != nil {
-		log.DefaultLogger.Warn("Error parsing json", err.Error())
-		log.DefaultLogger.Warn(fmt.Sprint(string(body)))
 		return nil, err
 	}
 
-	log.DefaultLogger.Info(fmt.Sprint(sdsType))
+	return createDataFrameFromSdsData(stream.Name, sdsType, sdsData)
+}
+
+func CommunityStreamsDataQuery(ctx context.Context, d *DataHubClient, communityId string, token string, self string, startIndex string, endIndex string) (*data.Frame, error) {
+	communityHeader := getCommunityHeader(communityId)
+
+	stream, sdsType, err := getCommunityStreamAndType(ctx, d, communityId, token, self)
+	if err != nil {
+		return nil, err
+	}
 
 	// get data
-	path = (basePath + "/streams/" + url.QueryEscape(id) + "/Data?startIndex=" + url.QueryEscape(startIndex) + "&endIndex=" + url.QueryEscape(endIndex))
-	body, err = SdsRequest(d, token, path, nil)
+	path := (self + "/Data")
+	sdsData, truncated, err := getCachedPagedSdsData(ctx, d, token, path, communityHeader, sdsType, startIndex, endIndex)
 	if err != nil {
 		return nil, err
 	}
 
-	var sdsData []map[string]interface{}
-	err = json.Unmarshal(body, &sdsData)
+	return createPagedDataFrameFromSdsData(ctx, d, stream.Name, sdsType, sdsData, truncated)
+}
+
+func CommunityStreamsInterpolatedDataQuery(ctx context.Context, d *DataHubClient, communityId string, token string, self string, startIndex string, endIndex string, count int) (*data.Frame, error) {
+	communityHeader := getCommunityHeader(communityId)
+
+	stream, sdsType, err := getCommunityStreamAndType(ctx, d, communityId, token, self)
+	if err != nil {
+		return nil, err
+	}
+
+	// get interpolated data
+	path := (self + "/Data/Interpolated?startIndex=" + url.QueryEscape(startIndex) + "&endIndex=" + url.QueryEscape(endIndex) + "&count=" + strconv.Itoa(count))
+	sdsData, err := getSdsData(ctx, d, token, path, communityHeader)
 	if err != nil {
-		log.DefaultLogger.Warn("Error parsing json", err.Error())
-		log.DefaultLogger.Warn(fmt.Sprint(string(body)))
 		return nil, err
 	}
 
 	return createDataFrameFromSdsData(stream.Name, sdsType, sdsData)
 }
 
-func CommunityStreamsDataQuery(d *DataHubClient, communityId string, token string, self string, startIndex string, endIndex string) (*data.Frame, error) {
+func StreamsSummariesDataQuery(ctx context.Context, d *DataHubClient, namespaceId string, token string, id string, startIndex string, endIndex string, count int, summaryTypes []string) (*data.Frame, error) {
+	basePath := d.resource + "/api/" + d.apiVersion + "/tenants/" + url.QueryEscape(d.tenantId) + "/namespaces/" + url.QueryEscape(namespaceId)
 
-	// make a community header
-	communityHeader := map[string]string{
-		"Community-Id": url.QueryEscape(communityId),
+	stream, sdsType, err := getStreamAndType(ctx, d, basePath, token, id)
+	if err != nil {
+		return nil, err
 	}
 
-	// get stream
-	path := self
-	body, err := SdsRequest(d, token, path, communityHeader)
+	// get summaries
+	path := (basePath + "/streams/" + url.QueryEscape(id) + "/Data/Summaries?startIndex=" + url.QueryEscape(startIndex) + "&endIndex=" + url.QueryEscape(endIndex) + "&count=" + strconv.Itoa(count))
+	sdsIntervals, err := getSdsIntervals(ctx, d, token, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return createDataFrameFromSdsSummaries(stream.Name, sdsType, sdsIntervals, summaryTypes)
+}
+
+func CommunityStreamsSummariesDataQuery(ctx context.Context, d *DataHubClient, communityId string, token string, self string, startIndex string, endIndex string, count int, summaryTypes []string) (*data.Frame, error) {
+	communityHeader := getCommunityHeader(communityId)
+
+	stream, sdsType, err := getCommunityStreamAndType(ctx, d, communityId, token, self)
+	if err != nil {
+		return nil, err
+	}
+
+	// get summaries
+	path := (self + "/Data/Summaries?startIndex=" + url.QueryEscape(startIndex) + "&endIndex=" + url.QueryEscape(endIndex) + "&count=" + strconv.Itoa(count))
+	sdsIntervals, err := getSdsIntervals(ctx, d, token, path, communityHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	return createDataFrameFromSdsSummaries(stream.Name, sdsType, sdsIntervals, summaryTypes)
+}
+
+func StreamsSampledDataQuery(ctx context.Context, d *DataHubClient, namespaceId string, token string, id string, startIndex string, endIndex string, intervals int, sampleBy string) (*data.Frame, error) {
+	basePath := d.resource + "/api/" + d.apiVersion + "/tenants/" + url.QueryEscape(d.tenantId) + "/namespaces/" + url.QueryEscape(namespaceId)
+
+	stream, sdsType, err := getStreamAndType(ctx, d, basePath, token, id)
+	if err != nil {
+		return nil, err
+	}
+
+	sampleBy, err = getSampleByProperty(sdsType, sampleBy)
 	if err != nil {
 		return nil, err
 	}
 
+	// get sampled data
+	path := (basePath + "/streams/" + url.QueryEscape(id) + "/Data/Sampled?startIndex=" + url.QueryEscape(startIndex) + "&endIndex=" + url.QueryEscape(endIndex) + "&intervals=" + strconv.Itoa(intervals) + "&sampleBy=" + url.QueryEscape(sampleBy))
+	sdsData, err := getSdsData(ctx, d, token, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return createDataFrameFromSdsData(stream.Name, sdsType, sdsData)
+}
+
+func CommunityStreamsSampledDataQuery(ctx context.Context, d *DataHubClient, communityId string, token string, self string, startIndex string, endIndex string, intervals int, sampleBy string) (*data.Frame, error) {
+	communityHeader := getCommunityHeader(communityId)
+
+	stream, sdsType, err := getCommunityStreamAndType(ctx, d, communityId, token, self)
+	if err != nil {
+		return nil, err
+	}
+
+	sampleBy, err = getSampleByProperty(sdsType, sampleBy)
+	if err != nil {
+		return nil, err
+	}
+
+	// get sampled data
+	path := (self + "/Data/Sampled?startIndex=" + url.QueryEscape(startIndex) + "&endIndex=" + url.QueryEscape(endIndex) + "&intervals=" + strconv.Itoa(intervals) + "&sampleBy=" + url.QueryEscape(sampleBy))
+	sdsData, err := getSdsData(ctx, d, token, path, communityHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	return createDataFrameFromSdsData(stream.Name, sdsType, sdsData)
+}
+
+// Validates the requested sampleBy property against the type, defaulting to the first numeric non-key property.
+func getSampleByProperty(sdsType sds.SdsType, sampleBy string) (string, error) {
+	for _, property := range sdsType.Properties {
+		if sampleBy != "" && property.Id == sampleBy && !property.IsKey {
+			return sampleBy, nil
+		}
+		if sampleBy == "" && !property.IsKey && isNumericSdsTypeCode(property.SdsType.SdsTypeCode) {
+			return property.Id, nil
+		}
+	}
+
+	if sampleBy != "" {
+		return "", backend.DownstreamErrorf("Property %s is not a sampleable property of type %s", sampleBy, sdsType.Id)
+	}
+	return "", backend.DownstreamErrorf("Type %s has no numeric property to sample by", sdsType.Id)
+}
+
+func isNumericSdsTypeCode(sdsTypeCode sds.SdsTypeCode) bool {
+	switch strings.TrimPrefix(string(sdsTypeCode), "Nullable") {
+	case "Int16", "UInt16", "Int32", "UInt32", "Int64", "UInt64", "Single", "Double", "Decimal":
+		return true
+	default:
+		return false
+	}
+}
+
+func StreamsLastValueQuery(ctx context.Context, d *DataHubClient, namespaceId string, token string, id string) (*data.Frame, error) {
+	return streamsSingleValueQuery(ctx, d, namespaceId, token, id, "Last")
+}
+
+func StreamsFirstValueQuery(ctx context.Context, d *DataHubClient, namespaceId string, token string, id string) (*data.Frame, error) {
+	return streamsSingleValueQuery(ctx, d, namespaceId, token, id, "First")
+}
+
+func CommunityStreamsLastValueQuery(ctx context.Context, d *DataHubClient, communityId string, token string, self string) (*data.Frame, error) {
+	return communityStreamsSingleValueQuery(ctx, d, communityId, token, self, "Last")
+}
+
+func CommunityStreamsFirstValueQuery(ctx context.Context, d *DataHubClient, communityId string, token string, self string) (*data.Frame, error) {
+	return communityStreamsSingleValueQuery(ctx, d, communityId, token, self, "First")
+}
+
+func streamsSingleValueQuery(ctx context.Context, d *DataHubClient, namespaceId string, token string, id string, position string) (*data.Frame, error) {
+	basePath := d.resource + "/api/" + d.apiVersion + "/tenants/" + url.QueryEscape(d.tenantId) + "/namespaces/" + url.QueryEscape(namespaceId)
+
+	stream, sdsType, err := getStreamAndType(ctx, d, basePath, token, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// get first or last value
+	path := (basePath + "/streams/" + url.QueryEscape(id) + "/Data/" + position)
+	sdsData, err := getSdsValue(ctx, d, token, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return createDataFrameFromSdsData(stream.Name, sdsType, sdsData)
+}
+
+func communityStreamsSingleValueQuery(ctx context.Context, d *DataHubClient, communityId string, token string, self string, position string) (*data.Frame, error) {
+	communityHeader := getCommunityHeader(communityId)
+
+	stream, sdsType, err := getCommunityStreamAndType(ctx, d, communityId, token, self)
+	if err != nil {
+		return nil, err
+	}
+
+	// get first or last value
+	path := (self + "/Data/" + position)
+	sdsData, err := getSdsValue(ctx, d, token, path, communityHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	return createDataFrameFromSdsData(stream.Name, sdsType, sdsData)
+}
+
+// Header naming the community a request reads from.
+const communityIdHeader = "Community-Id"
+
+func getCommunityHeader(communityId string) map[string]string {
+	return map[string]string{
+		communityIdHeader: url.QueryEscape(communityId),
+	}
+}
+
+func getStreamAndType(ctx context.Context, d *DataHubClient, basePath string, token string, id string) (sds.SdsStream, sds.SdsType, error) {
 	var stream sds.SdsStream
+	var sdsType sds.SdsType
+
+	// get type Id
+	path := (basePath + "/streams/" + url.QueryEscape(id))
+	body, err := sdsMetadataRequest(ctx, d, token, path, nil)
+	if err != nil {
+		return stream, sdsType, err
+	}
+
 	err = json.Unmarshal(body, &stream)
 	if err != nil {
-		log.DefaultLogger.Warn("Error parsing json", err.Error())
-		log.DefaultLogger.Warn(fmt.Sprint(string(body)))
-		return nil, err
+		logger.Warn("Error parsing json", err.Error())
+		logger.Warn(fmt.Sprint(string(body)))
+		return stream, sdsType, err
+	}
+
+	// get type info
+	path = (basePath + "/types/" + url.QueryEscape(stream.TypeId))
+	body, err = sdsMetadataRequest(ctx, d, token, path, nil)
+	if err != nil {
+		return stream, sdsType, err
+	}
+
+	err = json.Unmarshal(body, &sdsType)
+	if err != nil {
+		logger.Warn("Error parsing json", err.Error())
+		logger.Warn(fmt.Sprint(string(body)))
+		return stream, sdsType, err
+	}
+
+	logger.Debug("Stream type", "type", sdsType)
+
+	return stream, sdsType, nil
+}
+
+// Reads the stream and the target type of the stream view that reshapes its data.
+func getStreamAndStreamViewType(ctx context.Context, d *DataHubClient, basePath string, token string, id string, streamViewId string) (sds.SdsStream, sds.SdsType, error) {
+	var stream sds.SdsStream
+	var sdsType sds.SdsType
+
+	// get stream
+	path := (basePath + "/streams/" + url.QueryEscape(id))
+	body, err := sdsMetadataRequest(ctx, d, token, path, nil)
+	if err != nil {
+		return stream, sdsType, err
+	}
+
+	err = json.Unmarshal(body, &stream)
+	if err != nil {
+		logger.Warn("Error parsing json", err.Error())
+		logger.Warn(fmt.Sprint(string(body)))
+		return stream, sdsType, err
+	}
+
+	// get stream view map
+	path = (basePath + "/streamviews/" + url.QueryEscape(streamViewId) + "/Map")
+	body, err = sdsMetadataRequest(ctx, d, token, path, nil)
+	if err != nil {
+		return stream, sdsType, err
+	}
+
+	var streamViewMap sds.SdsStreamViewMap
+	err = json.Unmarshal(body, &streamViewMap)
+	if err != nil {
+		logger.Warn("Error parsing json", err.Error())
+		logger.Warn(fmt.Sprint(string(body)))
+		return stream, sdsType, err
+	}
+
+	if streamViewMap.SourceTypeId != "" && streamViewMap.SourceTypeId != stream.TypeId {
+		return stream, sdsType, backend.DownstreamErrorf("Stream view %s maps type %s, but stream %s is of type %s", streamViewId, streamViewMap.SourceTypeId, id, stream.TypeId)
+	}
+
+	// get target type info
+	path = (basePath + "/types/" + url.QueryEscape(streamViewMap.TargetTypeId))
+	body, err = sdsMetadataRequest(ctx, d, token, path, nil)
+	if err != nil {
+		return stream, sdsType, err
+	}
+
+	err = json.Unmarshal(body, &sdsType)
+	if err != nil {
+		logger.Warn("Error parsing json", err.Error())
+		logger.Warn(fmt.Sprint(string(body)))
+		return stream, sdsType, err
+	}
+
+	return stream, sdsType, nil
+}
+
+func getCommunityStreamAndType(ctx context.Context, d *DataHubClient, communityId string, token string, self string) (sds.SdsStream, sds.SdsType, error) {
+	communityHeader := getCommunityHeader(communityId)
+	var stream sds.SdsStream
+
+	// every community query reads the stream first, so this guards the data requests as well
+	err := validateCommunityStreamSelf(d, self)
+	if err != nil {
+		logger.Warn("Rejected community stream", "err", err)
+		return stream, sds.SdsType{}, err
+	}
+
+	// get stream
+	path := self
+	body, err := sdsMetadataRequest(ctx, d, token, path, communityHeader)
+	if err != nil {
+		return stream, sds.SdsType{}, err
+	}
+
+	err = json.Unmarshal(body, &stream)
+	if err != nil {
+		logger.Warn("Error parsing json", err.Error())
+		logger.Warn(fmt.Sprint(string(body)))
+		return stream, sds.SdsType{}, err
 	}
 
 	// get resolved type info
 	path = (self + "/resolved")
-	body, err = SdsRequest(d, token, path, communityHeader)
+	body, err = sdsMetadataRequest(ctx, d, token, path, communityHeader)
 	if err != nil {
-		return nil, err
+		return stream, sds.SdsType{}, err
 	}
 
 	var sdsResolvedStream sds.SdsResolvedStream
 	err = json.Unmarshal(body, &sdsResolvedStream)
 	if err != nil {
-		log.DefaultLogger.Warn("Error parsing json", err.Error())
-		log.DefaultLogger.Warn(fmt.Sprint(string(body)))
+		logger.Warn("Error parsing json", err.Error())
+		logger.Warn(fmt.Sprint(string(body)))
+		return stream, sds.SdsType{}, err
+	}
+
+	return stream, sdsResolvedStream.SdsType, nil
+}
+
+func getSdsData(ctx context.Context, d *DataHubClient, token string, path string, headers map[string]string) ([]map[string]interface{}, error) {
+	body, err := SdsRequest(ctx, d, token, path, headers)
+	if err != nil {
 		return nil, err
 	}
 
-	// get data
-	path = (self + "/Data?startIndex=" + url.QueryEscape(startIndex) + "&endIndex=" + url.QueryEscape(endIndex))
-	body, err = SdsRequest(d, token, path, communityHeader)
+	var sdsData []map[string]interface{}
+	err = sds.UnmarshalData(body, &sdsData)
 	if err != nil {
+		logger.Warn("Error parsing json", err.Error())
+		logger.Warn(fmt.Sprint(string(body)))
 		return nil, err
 	}
 
+	return sdsData, nil
+}
+
+// Reads a window of events page by page, following continuation tokens until the window is complete
+// or the client's event limit is reached. SDS answers with a plain list of events when everything
+// fits in one response, and with a page of results and a continuation token otherwise.
+func getPagedSdsData(ctx context.Context, d *DataHubClient, token string, path string, headers map[string]string) ([]map[string]interface{}, bool, error) {
 	var sdsData []map[string]interface{}
-	err = json.Unmarshal(body, &sdsData)
+	truncated, err := readSdsPages(ctx, d, token, path, headers, func(body []byte) (int, string, bool, error) {
+		var page sds.SdsResultPage
+		if err := sds.UnmarshalData(body, &page); err != nil {
+			return 0, "", false, err
+		}
+		sdsData = append(sdsData, page.Results...)
+		return len(page.Results), page.ContinuationToken, page.Paged, nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return sdsData, truncated, nil
+}
+
+// Requests the pages of path in turn, passing each response body to readPage, which returns the number
+// of results on the page, its continuation token and whether it was a page rather than a plain list.
+// Reading stops at the client's maximum number of events, reporting the result as truncated.
+func readSdsPages(ctx context.Context, d *DataHubClient, token string, path string, headers map[string]string, readPage func(body []byte) (int, string, bool, error)) (bool, error) {
+	read := 0
+	continuationToken := ""
+
+	for {
+		pageSize := d.pageSize
+		if remaining := d.maxEvents - read; remaining < pageSize {
+			pageSize = remaining
+		}
+
+		// SDS only returns a page with a continuation token when the parameter is present, even if empty
+		pagePath := path + "&count=" + strconv.Itoa(pageSize) + "&continuationToken=" + url.QueryEscape(continuationToken)
+
+		body, err := SdsRequest(ctx, d, token, pagePath, headers)
+		if err != nil {
+			return false, err
+		}
+
+		results, nextToken, paged, err := readPage(body)
+		if err != nil {
+			logger.Warn("Error parsing json", err.Error())
+			logger.Warn(fmt.Sprint(string(body)))
+			return false, err
+		}
+		read += results
+		continuationToken = nextToken
+
+		// a full plain list may have been cut off at the count, with no way to read the rest
+		if !paged && results >= pageSize {
+			return true, nil
+		}
+		if continuationToken == "" {
+			return false, nil
+		}
+		if read >= d.maxEvents {
+			return true, nil
+		}
+	}
+}
+
+// Reads a single event, returning an empty list when the stream has no data.
+func getSdsValue(ctx context.Context, d *DataHubClient, token string, path string, headers map[string]string) ([]map[string]interface{}, error) {
+	body, err := SdsRequest(ctx, d, token, path, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return []map[string]interface{}{}, nil
+	}
+
+	var sdsValue map[string]interface{}
+	err = sds.UnmarshalData(body, &sdsValue)
 	if err != nil {
-		log.DefaultLogger.Warn("Error parsing json", err.Error())
-		log.DefaultLogger.Warn(fmt.Sprint(string(body)))
+		logger.Warn("Error parsing json", err.Error())
+		logger.Warn(fmt.Sprint(string(body)))
 		return nil, err
 	}
 
-	return createDataFrameFromSdsData(stream.Name, sdsResolvedStream.SdsType, sdsData)
+	if sdsValue == nil {
+		return []map[string]interface{}{}, nil
+	}
+	return []map[string]interface{}{sdsValue}, nil
+}
+
+func getSdsIntervals(ctx context.Context, d *DataHubClient, token string, path string, headers map[string]string) ([]sds.SdsInterval, error) {
+	body, err := SdsRequest(ctx, d, token, path, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	var sdsIntervals []sds.SdsInterval
+	err = json.Unmarshal(body, &sdsIntervals)
+	if err != nil {
+		logger.Warn("Error parsing json", err.Error())
+		logger.Warn(fmt.Sprint(string(body)))
+		return nil, err
+	}
+
+	return sdsIntervals, nil
 }
 
 func createDataFrameFromSdsData(dataFrameName string, sdsType sds.SdsType, sdsData []map[string]interface{}) (*data.Frame, error) {
@@ -355,7 +981,80 @@ func createDataFrameFromSdsData(dataFrameName string, sdsType sds.SdsType, sdsDa
 	for i := 0; i < len(sdsData); i++ {
 		row := make([]interface{}, len(sdsType.Properties))
 		for j := 0; j < len(sdsType.Properties); j++ {
-			row[j] = convertSdsValue(sdsType.Properties[j].SdsType.SdsTypeCode, sdsData[i][string(sdsType.Properties[j].Id)])
+			row[j] = convertSdsPropertyValue(sdsType.Properties[j].SdsType, sdsData[i][string(sdsType.Properties[j].Id)])
+		}
+		frame.AppendRow(row...)
+	}
+
+	return frame, nil
+}
+
+func createPagedDataFrameFromSdsData(ctx context.Context, d *DataHubClient, dataFrameName string, sdsType sds.SdsType, sdsData []map[string]interface{}, truncated bool) (*data.Frame, error) {
+	frame, err := createDataFrameFromSdsData(dataFrameName, sdsType, sdsData)
+	if err != nil {
+		return nil, err
+	}
+
+	if truncated {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("Data truncated to the first %d events; narrow the time range to see the complete window", len(sdsData)),
+		})
+	}
+
+	return frame, nil
+}
+
+func createDataFrameFromSdsSummaries(dataFrameName string, sdsType sds.SdsType, sdsIntervals []sds.SdsInterval, summaryTypes []string) (*data.Frame, error) {
+	// create a dataframe
+	frame := data.NewFrame(dataFrameName)
+
+	// find the index property, which labels each interval by its start
+	keyIndex := -1
+	for i := 0; i < len(sdsType.Properties); i++ {
+		if sdsType.Properties[i].IsKey {
+			keyIndex = i
+			break
+		}
+	}
+	if keyIndex < 0 {
+		return nil, fmt.Errorf("Type %s has no key property", sdsType.Id)
+	}
+	keyProperty := sdsType.Properties[keyIndex]
+
+	// default to every summary type returned by SDS
+	if len(summaryTypes) == 0 {
+		summaryTypes = getSummaryTypes(sdsIntervals)
+	}
+
+	// create columns in dataframe, one per property and summary type combination
+	type summaryColumn struct {
+		propertyId  string
+		summaryType string
+	}
+	var columns []summaryColumn
+	frame.Fields = append(frame.Fields,
+		data.NewField(keyProperty.Id, nil, createSdsValueList(keyProperty.SdsType.SdsTypeCode)))
+	for i := 0; i < len(sdsType.Properties); i++ {
+		if i == keyIndex {
+			continue
+		}
+		for _, summaryType := range summaryTypes {
+			if !hasSummary(sdsIntervals, summaryType, sdsType.Properties[i].Id) {
+				continue
+			}
+			columns = append(columns, summaryColumn{sdsType.Properties[i].Id, summaryType})
+			frame.Fields = append(frame.Fields,
+				data.NewField(sdsType.Properties[i].Id+"."+summaryType, nil, []*float64{}))
+		}
+	}
+
+	// add data to rows
+	for i := 0; i < len(sdsIntervals); i++ {
+		row := make([]interface{}, len(columns)+1)
+		row[0] = convertSdsPropertyValue(keyProperty.SdsType, sdsIntervals[i].Start[keyProperty.Id])
+		for j, column := range columns {
+			row[j+1] = getSummaryValue(sdsIntervals[i], column.summaryType, column.propertyId)
 		}
 		frame.AppendRow(row...)
 	}
@@ -363,173 +1062,325 @@ func createDataFrameFromSdsData(dataFrameName string, sdsType sds.SdsType, sdsDa
 	return frame, nil
 }
 
+// Lists the summary types present in the intervals, in a stable order.
+func getSummaryTypes(sdsIntervals []sds.SdsInterval) []string {
+	found := map[string]bool{}
+	var summaryTypes []string
+	for _, interval := range sdsIntervals {
+		for summaryType := range interval.Summaries {
+			if !found[summaryType] {
+				found[summaryType] = true
+				summaryTypes = append(summaryTypes, summaryType)
+			}
+		}
+	}
+	sort.Strings(summaryTypes)
+	return summaryTypes
+}
+
+// Looks up a summary by name, ignoring case since SDS returns PascalCase names.
+func findSummary(interval sds.SdsInterval, summaryType string) map[string]interface{} {
+	if summary, ok := interval.Summaries[summaryType]; ok {
+		return summary
+	}
+	for name, summary := range interval.Summaries {
+		if strings.EqualFold(name, summaryType) {
+			return summary
+		}
+	}
+	return nil
+}
+
+func hasSummary(sdsIntervals []sds.SdsInterval, summaryType string, propertyId string) bool {
+	for _, interval := range sdsIntervals {
+		if _, ok := findSummary(interval, summaryType)[propertyId]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func getSummaryValue(interval sds.SdsInterval, summaryType string, propertyId string) *float64 {
+	value, ok := findSummary(interval, summaryType)[propertyId].(float64)
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// Creates the column for a property of the given type code. Value types are stored as their Go
+// equivalent, or a pointer to it for nullable type codes. Enumerations are stored as their underlying
+// integer type, TimeSpan as seconds, and Char, String, Guid, Version and any other type code as text.
 func createSdsValueList(sdsTypeCode sds.SdsTypeCode) interface{} {
-	switch t := sdsTypeCode; t {
-	case "DateTime":
+	nullable, baseTypeCode := splitSdsTypeCode(sdsTypeCode)
+	switch baseTypeCode {
+	case "DateTime", "DateTimeOffset":
+		if nullable {
+			return []*time.Time{}
+		}
 		return []time.Time{}
-	case "NullableDateTime":
-		return []*time.Time{}
 	case "Boolean":
+		if nullable {
+			return []*bool{}
+		}
 		return []bool{}
-	case "NullableBoolean":
-		return []*bool{}
+	case "SByte":
+		if nullable {
+			return []*int8{}
+		}
+		return []int8{}
+	case "Byte":
+		if nullable {
+			return []*uint8{}
+		}
+		return []uint8{}
 	case "Int16":
+		if nullable {
+			return []*int16{}
+		}
 		return []int16{}
-	case "NullableInt16":
-		return []*int16{}
 	case "UInt16":
+		if nullable {
+			return []*uint16{}
+		}
 		return []uint16{}
-	case "NullableUInt16":
-		return []*uint16{}
 	case "Int32":
+		if nullable {
+			return []*int32{}
+		}
 		return []int32{}
-	case "NullableInt32":
-		return []*int32{}
 	case "UInt32":
+		if nullable {
+			return []*uint32{}
+		}
 		return []uint32{}
-	case "NullableUInt32":
-		return []*uint32{}
 	case "Int64":
+		if nullable {
+			return []*int64{}
+		}
 		return []int64{}
-	case "NullableInt64":
-		return []*int64{}
 	case "UInt64":
+		if nullable {
+			return []*uint64{}
+		}
 		return []uint64{}
-	case "NullableUInt64":
-		return []*uint32{}
 	case "Single":
+		if nullable {
+			return []*float32{}
+		}
 		return []float32{}
-	case "NullableSingle":
-		return []*float32{}
-	case "Double":
+	case "Double", "Decimal", "TimeSpan":
+		if nullable {
+			return []*float64{}
+		}
 		return []float64{}
-	case "NullableDouble":
-		return []*float64{}
 	default:
 		return []*string{}
 	}
 }
 
+// Converts a JSON value of the given type code to the element type of its column. SDS omits default
+// values from responses, so missing values of non-nullable types become their zero value. Values that
+// cannot be converted are treated as missing rather than failing the query.
 func convertSdsValue(sdsTypeCode sds.SdsTypeCode, value interface{}) interface{} {
+	nullable, baseTypeCode := splitSdsTypeCode(sdsTypeCode)
 
-	switch t := sdsTypeCode; t {
-	case "DateTime":
-		if value == nil {
-			return value
-		}
-		timestamp, _ := time.Parse(time.RFC3339, value.(string))
-		return timestamp
-	case "NullableDateTime":
-		if value == nil {
-			return value
-		}
-		timestamp, _ := time.Parse(time.RFC3339, value.(string))
-		return &timestamp
+	var converted interface{}
+	var ok bool
+	switch baseTypeCode {
+	case "DateTime", "DateTimeOffset":
+		converted, ok = convertSdsTime(value)
 	case "Boolean":
-		if value == nil {
-			return false
-		}
-		return true
-	case "NullableBoolean":
-		if value == nil {
-			return value
-		}
-		valuePointer := true
-		return &valuePointer
+		converted, ok = convertSdsBool(value)
+	case "SByte":
+		converted, ok = convertSdsInteger(value, func(i int64) interface{} { return int8(i) })
+	case "Byte":
+		converted, ok = convertSdsUnsigned(value, func(u uint64) interface{} { return uint8(u) })
 	case "Int16":
-		if value == nil {
-			return int16(0)
-		}
-		return int16(value.(float64))
-	case "NullableInt16":
-		if value == nil {
-			return value
-		}
-		valuePointer := int16(value.(float64))
-		return &valuePointer
+		converted, ok = convertSdsInteger(value, func(i int64) interface{} { return int16(i) })
 	case "UInt16":
-		if value == nil {
-			return uint16(0)
-		}
-		return uint16(value.(float64))
-	case "NullableUInt16":
-		if value == nil {
-			return value
-		}
-		valuePointer := uint16(value.(float64))
-		return &valuePointer
+		converted, ok = convertSdsUnsigned(value, func(u uint64) interface{} { return uint16(u) })
 	case "Int32":
-		if value == nil {
-			return int32(0)
-		}
-		return int32(value.(float64))
-	case "NullableInt32":
-		if value == nil {
-			return value
-		}
-		valuePointer := int32(value.(float64))
-		return &valuePointer
+		converted, ok = convertSdsInteger(value, func(i int64) interface{} { return int32(i) })
 	case "UInt32":
-		if value == nil {
-			return uint32(0)
+		converted, ok = convertSdsUnsigned(value, func(u uint64) interface{} { return uint32(u) })
+	case "Int64":
+		converted, ok = convertSdsInteger(value, func(i int64) interface{} { return i })
+	case "UInt64":
+		converted, ok = convertSdsUnsigned(value, func(u uint64) interface{} { return u })
+	case "Single":
+		converted, ok = convertSdsNumber(value, func(f float64) interface{} { return float32(f) })
+	case "Double", "Decimal":
+		converted, ok = convertSdsNumber(value, func(f float64) interface{} { return f })
+	case "TimeSpan":
+		converted, ok = convertSdsTimeSpan(value)
+	default:
+		// text is always nullable
+		text, ok := convertSdsText(value)
+		if !ok {
+			return nil
 		}
-		return uint32(value.(float64))
-	case "NullableUInt32":
-		if value == nil {
-			return value
+		return &text
+	}
+
+	if !ok {
+		if nullable {
+			return nil
 		}
-		valuePointer := uint32(value.(float64))
-		return &valuePointer
-	case "Int64":
-		if value == nil {
-			return int64(0)
+		return reflect.Zero(reflect.TypeOf(createSdsValueList(baseTypeCode)).Elem()).Interface()
+	}
+	if nullable {
+		pointer := reflect.New(reflect.TypeOf(converted))
+		pointer.Elem().Set(reflect.ValueOf(converted))
+		return pointer.Interface()
+	}
+	return converted
+}
+
+// Converts a property value, resolving enumeration values given by name to their numeric value.
+func convertSdsPropertyValue(propertyType sds.SdsType, value interface{}) interface{} {
+	if name, ok := value.(string); ok && strings.HasSuffix(string(propertyType.SdsTypeCode), "Enum") {
+		for _, member := range propertyType.Properties {
+			if strings.EqualFold(member.Id, name) || strings.EqualFold(member.Name, name) {
+				value = member.Value
+				break
+			}
 		}
-		return int64(value.(float64))
-	case "NullableInt64":
-		if value == nil {
-			return value
+	}
+	return convertSdsValue(propertyType.SdsTypeCode, value)
+}
+
+// Splits a type code into whether it is nullable and the code of its value type, with enumerations
+// reduced to their underlying integer type.
+func splitSdsTypeCode(sdsTypeCode sds.SdsTypeCode) (bool, sds.SdsTypeCode) {
+	typeCode := string(sdsTypeCode)
+	nullable := strings.HasPrefix(typeCode, "Nullable")
+	typeCode = strings.TrimSuffix(strings.TrimPrefix(typeCode, "Nullable"), "Enum")
+	return nullable, sds.SdsTypeCode(typeCode)
+}
+
+func convertSdsTime(value interface{}) (interface{}, bool) {
+	text, ok := value.(string)
+	if !ok {
+		return nil, false
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, text)
+	if err != nil {
+		// DateTime values without an offset are in UTC
+		timestamp, err = time.Parse("2006-01-02T15:04:05.999999999", text)
+		if err != nil {
+			return nil, false
 		}
-		valuePointer := int64(value.(float64))
-		return &valuePointer
-	case "UInt64":
-		if value == nil {
-			return uint64(0)
+	}
+	return timestamp, true
+}
+
+func convertSdsBool(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case bool:
+		return v, true
+	case float64:
+		return v != 0, true
+	case json.Number:
+		f, err := v.Float64()
+		return f != 0, err == nil
+	case string:
+		b, err := strconv.ParseBool(v)
+		return b, err == nil
+	default:
+		return nil, false
+	}
+}
+
+// Converts a value to a signed integer type. Numbers and text holding an integer are parsed directly,
+// as a float64 cannot represent every Int64 value; other values are converted through a float64.
+func convertSdsInteger(value interface{}, convert func(int64) interface{}) (interface{}, bool) {
+	if text, ok := sdsNumberText(value); ok {
+		if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return convert(i), true
 		}
-		return uint64(value.(float64))
-	case "NullableUInt64":
-		if value == nil {
-			return value
+	}
+	return convertSdsNumber(value, func(f float64) interface{} { return convert(int64(f)) })
+}
+
+// Converts a value to an unsigned integer type, parsing integers directly like convertSdsInteger.
+func convertSdsUnsigned(value interface{}, convert func(uint64) interface{}) (interface{}, bool) {
+	if text, ok := sdsNumberText(value); ok {
+		if u, err := strconv.ParseUint(text, 10, 64); err == nil {
+			return convert(u), true
 		}
-		valuePointer := uint64(value.(float64))
-		return &valuePointer
-	case "Single":
-		if value == nil {
-			return float32(0)
-		}
-		return float32(value.(float64))
-	case "NullableSingle":
-		if value == nil {
-			return value
-		}
-		valuePointer := float32(value.(float64))
-		return &valuePointer
-	case "Double":
-		if value == nil {
-			return float64(0)
-		}
-		return value.(float64)
-	case "NullableDouble":
-		if value == nil {
-			return value
-		}
-		valuePointer := value.(float64)
-		return &valuePointer
+	}
+	return convertSdsNumber(value, func(f float64) interface{} { return convert(uint64(f)) })
+}
+
+func sdsNumberText(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		return string(v), true
+	case string:
+		return v, true
+	default:
+		return "", false
+	}
+}
+
+func convertSdsNumber(value interface{}, convert func(float64) interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case float64:
+		return convert(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return convert(f), err == nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return convert(f), err == nil
+	case bool:
+		if v {
+			return convert(1), true
+		}
+		return convert(0), true
+	default:
+		return nil, false
+	}
+}
+
+var timeSpanPattern = regexp.MustCompile(`^(-)?(?:(\d+)\.)?(\d+):(\d+):(\d+)(?:\.(\d+))?$`)
+
+// Converts a TimeSpan in the .NET [-][d.]hh:mm:ss[.fffffff] format to seconds.
+func convertSdsTimeSpan(value interface{}) (interface{}, bool) {
+	text, ok := value.(string)
+	if !ok {
+		return convertSdsNumber(value, func(f float64) interface{} { return f })
+	}
+
+	match := timeSpanPattern.FindStringSubmatch(text)
+	if match == nil {
+		return nil, false
+	}
+	days, _ := strconv.ParseFloat("0"+match[2], 64)
+	hours, _ := strconv.ParseFloat(match[3], 64)
+	minutes, _ := strconv.ParseFloat(match[4], 64)
+	seconds, _ := strconv.ParseFloat(match[5]+"."+match[6]+"0", 64)
+
+	total := ((days*24+hours)*60+minutes)*60 + seconds
+	if match[1] == "-" {
+		total = -total
+	}
+	return total, true
+}
+
+// Converts text values as they are, and anything else, such as a Version given as an object, to JSON.
+func convertSdsText(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
 	default:
-		log.DefaultLogger.Info("Default")
-		if value == nil {
-			return value
+		text, err := json.Marshal(v)
+		if err != nil {
+			return "", false
 		}
-		valuePointer := value.(string)
-		return &valuePointer
+		return string(text), true
 	}
 }
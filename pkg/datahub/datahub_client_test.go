@@ -1,14 +1,18 @@
 package datahub
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/osisoft/sample-adh-grafana_backend_plugin-datasource/pkg/datahub/sds"
 )
 
 type Tests struct {
@@ -69,7 +73,7 @@ func TestStreamsQuery(t *testing.T) {
 			defer test.server.Close()
 
 			client := NewDataHubClient(test.server.URL, apiVersion, tenantId, "", "")
-			resp, err := StreamsQuery(&client, namespaceId, "token", "")
+			resp, err := StreamsQuery(context.Background(), &client, namespaceId, "token", "")
 
 			if !reflect.DeepEqual(resp, test.response) {
 				t.Errorf("FAILED: expected %v, got %v\n", test.response, resp)
@@ -201,7 +205,7 @@ func TestStreamsDataQuery(t *testing.T) {
 			defer test.server.Close()
 
 			client := NewDataHubClient(test.server.URL, apiVersion, tenantId, "", "")
-			resp, err := StreamsDataQuery(&client, namespaceId, "token", "StreamId1", "", "")
+			resp, err := StreamsDataQuery(context.Background(), &client, namespaceId, "token", "StreamId1", "", "")
 
 			if !reflect.DeepEqual(resp, test.response) {
 				t.Errorf("FAILED: expected %v, got %v\n", test.response, resp)
@@ -268,7 +272,7 @@ func TestCommunityStreamsQuery(t *testing.T) {
 			defer test.server.Close()
 
 			client := NewDataHubClient(test.server.URL, apiVersion, tenantId, "", "")
-			resp, err := CommunityStreamsQuery(&client, namespaceId, "token", "")
+			resp, err := CommunityStreamsQuery(context.Background(), &client, namespaceId, "token", "")
 
 			if !reflect.DeepEqual(resp, test.response) {
 				t.Errorf("FAILED: expected %v, got %v\n", test.response, resp)
@@ -401,7 +405,7 @@ func TestCommunityStreamsDataQuery(t *testing.T) {
 			defer test.server.Close()
 
 			client := NewDataHubClient(test.server.URL, apiVersion, tenantId, "", "")
-			resp, err := CommunityStreamsDataQuery(&client, communityId, "token", test.server.URL+basePath+"/streams/StreamId1", "", "")
+			resp, err := CommunityStreamsDataQuery(context.Background(), &client, communityId, "token", test.server.URL+basePath+"/streams/StreamId1", "", "")
 
 			if !reflect.DeepEqual(resp, test.response) {
 				t.Errorf("FAILED: expected %v, got %v\n", test.response, resp)
@@ -412,3 +416,709 @@ func TestCommunityStreamsDataQuery(t *testing.T) {
 		})
 	}
 }
+
+// Registers the stream and type routes shared by the namespace stream data tests.
+func newStreamsTestMux(basePath string) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(basePath+"/streams/StreamId1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`
+			{
+				"TypeId": "StreamType1",
+				"Id": "StreamId1",
+				"Name": "StreamName1",
+				"Description": ""
+			}`))
+	})
+
+	mux.HandleFunc(basePath+"/types/StreamType1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`
+		{
+			"Id": "StreamType1",
+			"Name": "StreamType1",
+			"SdsTypeCode": 1,
+			"Properties": [
+				{
+					"Id": "Timestamp",
+					"Name": "Timestamp",
+					"IsKey": true,
+					"SdsType": {
+						"Id": "PropertyId1",
+						"Name": "DateTime",
+						"SdsTypeCode": 16
+					}
+				},
+				{
+					"Id": "Value",
+					"Name": "Value",
+					"IsKey": false,
+					"SdsType": {
+						"Id": "PropertyId2",
+						"Name": "Double",
+						"SdsTypeCode": 14
+					}
+				}
+			]
+		}`))
+	})
+
+	return mux
+}
+
+func TestStreamsInterpolatedDataQuery(t *testing.T) {
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	mux := newStreamsTestMux(basePath)
+
+	mux.HandleFunc(basePath+"/streams/StreamId1/Data/Interpolated", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("count") != "3" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[
+			{
+				"Timestamp": "2022-06-04T00:00:00Z",
+				"Value": 0
+			},
+			{
+				"Timestamp": "2022-06-04T12:00:00Z",
+				"Value": 0.5
+			},
+			{
+				"Timestamp": "2022-06-05T00:00:00Z",
+				"Value": 1
+			}
+		]`))
+	})
+
+	tests := []Tests{
+		{
+			name:   "streams-interpolated-data-query",
+			server: httptest.NewServer(mux),
+			response: data.NewFrame("StreamName1",
+				data.NewField("Timestamp", nil, []time.Time{time.Date(2022, 6, 4, 0, 0, 0, 0, time.UTC), time.Date(2022, 6, 4, 12, 0, 0, 0, time.UTC), time.Date(2022, 6, 5, 0, 0, 0, 0, time.UTC)}),
+				data.NewField("Value", nil, []float64{0, 0.5, 1}),
+			),
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer test.server.Close()
+
+			client := NewDataHubClient(test.server.URL, apiVersion, tenantId, "", "")
+			resp, err := StreamsInterpolatedDataQuery(context.Background(), &client, namespaceId, "token", "StreamId1", "", "", 3)
+
+			if !reflect.DeepEqual(resp, test.response) {
+				t.Errorf("FAILED: expected %v, got %v\n", test.response, resp)
+			}
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Expected error FAILED: expected %v, got %v\n", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestStreamsSummariesDataQuery(t *testing.T) {
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	mux := newStreamsTestMux(basePath)
+
+	mux.HandleFunc(basePath+"/streams/StreamId1/Data/Summaries", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("count") != "2" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[
+			{
+				"Start": { "Timestamp": "2022-06-04T00:00:00Z", "Value": 0 },
+				"End": { "Timestamp": "2022-06-04T12:00:00Z", "Value": 1 },
+				"Summaries": {
+					"Average": { "Value": 0.5 },
+					"Maximum": { "Value": 1 },
+					"Minimum": { "Value": 0 }
+				}
+			},
+			{
+				"Start": { "Timestamp": "2022-06-04T12:00:00Z", "Value": 1 },
+				"End": { "Timestamp": "2022-06-05T00:00:00Z", "Value": 3 },
+				"Summaries": {
+					"Average": { "Value": 2 },
+					"Maximum": { "Value": 3 },
+					"Minimum": { "Value": 1 }
+				}
+			}
+		]`))
+	})
+
+	average := []float64{0.5, 2}
+	maximum := []float64{1, 3}
+	minimum := []float64{0, 1}
+
+	tests := []struct {
+		Tests
+		summaryTypes []string
+	}{
+		{
+			Tests: Tests{
+				name:   "streams-summaries-data-query",
+				server: httptest.NewServer(mux),
+				response: data.NewFrame("StreamName1",
+					data.NewField("Timestamp", nil, []time.Time{time.Date(2022, 6, 4, 0, 0, 0, 0, time.UTC), time.Date(2022, 6, 4, 12, 0, 0, 0, time.UTC)}),
+					data.NewField("Value.Minimum", nil, []*float64{&minimum[0], &minimum[1]}),
+					data.NewField("Value.Average", nil, []*float64{&average[0], &average[1]}),
+				),
+				expectedError: nil,
+			},
+			summaryTypes: []string{"Minimum", "Average"},
+		},
+		{
+			Tests: Tests{
+				name:   "streams-summaries-data-query-all-types",
+				server: httptest.NewServer(mux),
+				response: data.NewFrame("StreamName1",
+					data.NewField("Timestamp", nil, []time.Time{time.Date(2022, 6, 4, 0, 0, 0, 0, time.UTC), time.Date(2022, 6, 4, 12, 0, 0, 0, time.UTC)}),
+					data.NewField("Value.Average", nil, []*float64{&average[0], &average[1]}),
+					data.NewField("Value.Maximum", nil, []*float64{&maximum[0], &maximum[1]}),
+					data.NewField("Value.Minimum", nil, []*float64{&minimum[0], &minimum[1]}),
+				),
+				expectedError: nil,
+			},
+			summaryTypes: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer test.server.Close()
+
+			client := NewDataHubClient(test.server.URL, apiVersion, tenantId, "", "")
+			resp, err := StreamsSummariesDataQuery(context.Background(), &client, namespaceId, "token", "StreamId1", "", "", 2, test.summaryTypes)
+
+			if !reflect.DeepEqual(resp, test.response) {
+				t.Errorf("FAILED: expected %v, got %v\n", test.response, resp)
+			}
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Expected error FAILED: expected %v, got %v\n", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestStreamsSampledDataQuery(t *testing.T) {
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	mux := newStreamsTestMux(basePath)
+
+	mux.HandleFunc(basePath+"/streams/StreamId1/Data/Sampled", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("intervals") != "1" || r.URL.Query().Get("sampleBy") != "Value" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[
+			{
+				"Timestamp": "2022-06-04T00:00:00Z",
+				"Value": 0
+			},
+			{
+				"Timestamp": "2022-06-04T06:00:00Z",
+				"Value": 5
+			},
+			{
+				"Timestamp": "2022-06-05T00:00:00Z",
+				"Value": 1
+			}
+		]`))
+	})
+
+	tests := []struct {
+		Tests
+		sampleBy string
+	}{
+		{
+			Tests: Tests{
+				name:   "streams-sampled-data-query",
+				server: httptest.NewServer(mux),
+				response: data.NewFrame("StreamName1",
+					data.NewField("Timestamp", nil, []time.Time{time.Date(2022, 6, 4, 0, 0, 0, 0, time.UTC), time.Date(2022, 6, 4, 6, 0, 0, 0, time.UTC), time.Date(2022, 6, 5, 0, 0, 0, 0, time.UTC)}),
+					data.NewField("Value", nil, []float64{0, 5, 1}),
+				),
+				expectedError: nil,
+			},
+			sampleBy: "",
+		},
+		{
+			Tests: Tests{
+				name:          "streams-sampled-data-query-invalid-property",
+				server:        httptest.NewServer(mux),
+				response:      nil,
+				expectedError: nil,
+			},
+			sampleBy: "Timestamp",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer test.server.Close()
+
+			client := NewDataHubClient(test.server.URL, apiVersion, tenantId, "", "")
+			resp, err := StreamsSampledDataQuery(context.Background(), &client, namespaceId, "token", "StreamId1", "", "", 1, test.sampleBy)
+
+			if !reflect.DeepEqual(resp, test.response) {
+				t.Errorf("FAILED: expected %v, got %v\n", test.response, resp)
+			}
+			if test.response == nil && err == nil {
+				t.Errorf("Expected error FAILED: expected an error, got nil\n")
+			}
+			if test.response != nil && !errors.Is(err, test.expectedError) {
+				t.Errorf("Expected error FAILED: expected %v, got %v\n", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestStreamsLastValueQuery(t *testing.T) {
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	mux := newStreamsTestMux(basePath)
+
+	mux.HandleFunc(basePath+"/streams/StreamId1/Data/Last", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`
+			{
+				"Timestamp": "2022-06-05T00:00:00Z",
+				"Value": 1
+			}`))
+	})
+
+	emptyMux := newStreamsTestMux(basePath)
+	emptyMux.HandleFunc(basePath+"/streams/StreamId1/Data/Last", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []Tests{
+		{
+			name:   "streams-last-value-query",
+			server: httptest.NewServer(mux),
+			response: data.NewFrame("StreamName1",
+				data.NewField("Timestamp", nil, []time.Time{time.Date(2022, 6, 5, 0, 0, 0, 0, time.UTC)}),
+				data.NewField("Value", nil, []float64{1}),
+			),
+			expectedError: nil,
+		},
+		{
+			name:   "streams-last-value-query-no-data",
+			server: httptest.NewServer(emptyMux),
+			response: data.NewFrame("StreamName1",
+				data.NewField("Timestamp", nil, []time.Time{}),
+				data.NewField("Value", nil, []float64{}),
+			),
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer test.server.Close()
+
+			client := NewDataHubClient(test.server.URL, apiVersion, tenantId, "", "")
+			resp, err := StreamsLastValueQuery(context.Background(), &client, namespaceId, "token", "StreamId1")
+
+			if !reflect.DeepEqual(resp, test.response) {
+				t.Errorf("FAILED: expected %v, got %v\n", test.response, resp)
+			}
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Expected error FAILED: expected %v, got %v\n", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestStreamsFirstValueQuery(t *testing.T) {
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	mux := newStreamsTestMux(basePath)
+
+	mux.HandleFunc(basePath+"/streams/StreamId1/Data/First", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`
+			{
+				"Timestamp": "2022-06-04T00:00:00Z",
+				"Value": 0
+			}`))
+	})
+
+	tests := []Tests{
+		{
+			name:   "streams-first-value-query",
+			server: httptest.NewServer(mux),
+			response: data.NewFrame("StreamName1",
+				data.NewField("Timestamp", nil, []time.Time{time.Date(2022, 6, 4, 0, 0, 0, 0, time.UTC)}),
+				data.NewField("Value", nil, []float64{0}),
+			),
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer test.server.Close()
+
+			client := NewDataHubClient(test.server.URL, apiVersion, tenantId, "", "")
+			resp, err := StreamsFirstValueQuery(context.Background(), &client, namespaceId, "token", "StreamId1")
+
+			if !reflect.DeepEqual(resp, test.response) {
+				t.Errorf("FAILED: expected %v, got %v\n", test.response, resp)
+			}
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Expected error FAILED: expected %v, got %v\n", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestStreamsDataQueryPaged(t *testing.T) {
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	mux := newStreamsTestMux(basePath)
+
+	mux.HandleFunc(basePath+"/streams/StreamId1/Data", func(w http.ResponseWriter, r *http.Request) {
+		// like SDS, only page when a continuation token parameter is present
+		if _, ok := r.URL.Query()["continuationToken"]; !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		switch r.URL.Query().Get("continuationToken") {
+		case "":
+			w.Write([]byte(`{
+				"Results": [
+					{ "Timestamp": "2022-06-04T00:00:00Z", "Value": 0 },
+					{ "Timestamp": "2022-06-05T00:00:00Z", "Value": 1 }
+				],
+				"ContinuationToken": "page2"
+			}`))
+		case "page2":
+			w.Write([]byte(`{
+				"Results": [
+					{ "Timestamp": "2022-06-06T00:00:00Z", "Value": 2 },
+					{ "Timestamp": "2022-06-07T00:00:00Z", "Value": 3 }
+				],
+				"ContinuationToken": null
+			}`))
+		}
+	})
+
+	complete := data.NewFrame("StreamName1",
+		data.NewField("Timestamp", nil, []time.Time{time.Date(2022, 6, 4, 0, 0, 0, 0, time.UTC), time.Date(2022, 6, 5, 0, 0, 0, 0, time.UTC), time.Date(2022, 6, 6, 0, 0, 0, 0, time.UTC), time.Date(2022, 6, 7, 0, 0, 0, 0, time.UTC)}),
+		data.NewField("Value", nil, []float64{0, 1, 2, 3}),
+	)
+
+	truncated := data.NewFrame("StreamName1",
+		data.NewField("Timestamp", nil, []time.Time{time.Date(2022, 6, 4, 0, 0, 0, 0, time.UTC), time.Date(2022, 6, 5, 0, 0, 0, 0, time.UTC)}),
+		data.NewField("Value", nil, []float64{0, 1}),
+	)
+	truncated.AppendNotices(data.Notice{
+		Severity: data.NoticeSeverityWarning,
+		Text:     "Data truncated to the first 2 events; narrow the time range to see the complete window",
+	})
+
+	// servers that do not page return a plain list, cut off at the count
+	unpagedMux := newStreamsTestMux(basePath)
+	unpagedMux.HandleFunc(basePath+"/streams/StreamId1/Data", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[
+			{ "Timestamp": "2022-06-04T00:00:00Z", "Value": 0 },
+			{ "Timestamp": "2022-06-05T00:00:00Z", "Value": 1 }
+		]`))
+	})
+
+	tests := []struct {
+		Tests
+		maxEvents int
+	}{
+		{
+			Tests: Tests{
+				name:          "streams-data-query-unpaged-full",
+				server:        httptest.NewServer(unpagedMux),
+				response:      truncated,
+				expectedError: nil,
+			},
+			maxEvents: 10,
+		},
+		{
+			Tests: Tests{
+				name:          "streams-data-query-paged",
+				server:        httptest.NewServer(mux),
+				response:      complete,
+				expectedError: nil,
+			},
+			maxEvents: 10,
+		},
+		{
+			Tests: Tests{
+				name:          "streams-data-query-paged-truncated",
+				server:        httptest.NewServer(mux),
+				response:      truncated,
+				expectedError: nil,
+			},
+			maxEvents: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer test.server.Close()
+
+			client := NewDataHubClient(test.server.URL, apiVersion, tenantId, "", "")
+			client.SetPaging(2, test.maxEvents)
+			resp, err := StreamsDataQuery(context.Background(), &client, namespaceId, "token", "StreamId1", "", "")
+
+			if !reflect.DeepEqual(resp, test.response) {
+				t.Errorf("FAILED: expected %v, got %v\n", test.response, resp)
+			}
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Expected error FAILED: expected %v, got %v\n", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestStreamsStreamViewDataQuery(t *testing.T) {
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	mux := newStreamsTestMux(basePath)
+
+	mux.HandleFunc(basePath+"/streamviews/StreamViewId1/Map", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`
+			{
+				"SourceTypeId": "StreamType1",
+				"TargetTypeId": "TargetType1",
+				"Properties": [
+					{ "SourceId": "Timestamp", "TargetId": "Time", "Mode": 0 },
+					{ "SourceId": "Value", "TargetId": "Temperature", "Mode": 0 }
+				]
+			}`))
+	})
+
+	mux.HandleFunc(basePath+"/types/TargetType1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`
+			{
+				"Id": "TargetType1",
+				"SdsTypeCode": 1,
+				"Properties": [
+					{ "Id": "Time", "IsKey": true, "SdsType": { "SdsTypeCode": 16 } },
+					{ "Id": "Temperature", "IsKey": false, "SdsType": { "SdsTypeCode": 14 } }
+				]
+			}`))
+	})
+
+	mux.HandleFunc(basePath+"/streams/StreamId1/Data/Transform", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("streamViewId") != "StreamViewId1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[
+			{
+				"Time": "2022-06-04T00:00:00Z",
+				"Temperature": 20.5
+			}
+		]`))
+	})
+
+	tests := []Tests{
+		{
+			name:   "streams-stream-view-data-query",
+			server: httptest.NewServer(mux),
+			response: data.NewFrame("StreamName1",
+				data.NewField("Time", nil, []time.Time{time.Date(2022, 6, 4, 0, 0, 0, 0, time.UTC)}),
+				data.NewField("Temperature", nil, []float64{20.5}),
+			),
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer test.server.Close()
+
+			client := NewDataHubClient(test.server.URL, apiVersion, tenantId, "", "")
+			resp, err := StreamsStreamViewDataQuery(context.Background(), &client, namespaceId, "token", "StreamId1", "StreamViewId1", "", "")
+
+			if !reflect.DeepEqual(resp, test.response) {
+				t.Errorf("FAILED: expected %v, got %v\n", test.response, resp)
+			}
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Expected error FAILED: expected %v, got %v\n", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestSdsRequestCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	client := NewDataHubClient(server.URL, apiVersion, tenantId, "", "")
+	_, err := SdsRequest(ctx, &client, "token", server.URL, nil)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected error FAILED: expected %v, got %v\n", context.DeadlineExceeded, err)
+	}
+}
+
+func TestConvertSdsValue(t *testing.T) {
+	type conversionTests struct {
+		name        string
+		sdsTypeCode sds.SdsTypeCode
+		value       interface{}
+		expected    interface{}
+	}
+
+	uint64Value := uint64(42)
+	timestamp := time.Date(2022, 6, 4, 2, 0, 0, 0, time.FixedZone("", 2*60*60))
+	text := "Plant 1"
+
+	tests := []conversionTests{
+		{name: "date-time-offset", sdsTypeCode: "DateTimeOffset", value: "2022-06-04T02:00:00+02:00", expected: timestamp},
+		{name: "date-time-fraction", sdsTypeCode: "DateTime", value: "2022-06-04T00:00:00.1234567Z", expected: time.Date(2022, 6, 4, 0, 0, 0, 123456700, time.UTC)},
+		{name: "date-time-missing", sdsTypeCode: "DateTime", value: nil, expected: time.Time{}},
+		{name: "boolean-false", sdsTypeCode: "Boolean", value: false, expected: false},
+		{name: "boolean-true", sdsTypeCode: "Boolean", value: true, expected: true},
+		{name: "byte", sdsTypeCode: "Byte", value: float64(200), expected: uint8(200)},
+		{name: "sbyte", sdsTypeCode: "SByte", value: float64(-5), expected: int8(-5)},
+		{name: "nullable-uint64", sdsTypeCode: "NullableUInt64", value: float64(42), expected: &uint64Value},
+		{name: "nullable-missing", sdsTypeCode: "NullableInt32", value: nil, expected: nil},
+		{name: "decimal", sdsTypeCode: "Decimal", value: float64(1.25), expected: float64(1.25)},
+		{name: "decimal-text", sdsTypeCode: "Decimal", value: "1.25", expected: float64(1.25)},
+		{name: "time-span", sdsTypeCode: "TimeSpan", value: "1.02:03:04.5", expected: float64(93784.5)},
+		{name: "negative-time-span", sdsTypeCode: "TimeSpan", value: "-00:00:01", expected: float64(-1)},
+		{name: "enum", sdsTypeCode: "Int32Enum", value: float64(2), expected: int32(2)},
+		{name: "byte-enum", sdsTypeCode: "ByteEnum", value: float64(3), expected: uint8(3)},
+		{name: "string", sdsTypeCode: "String", value: "Plant 1", expected: &text},
+		{name: "string-missing", sdsTypeCode: "String", value: nil, expected: nil},
+		{name: "int-invalid", sdsTypeCode: "Int32", value: map[string]interface{}{"Major": 1}, expected: int32(0)},
+		{name: "int64-beyond-float", sdsTypeCode: "Int64", value: json.Number("9007199254740993"), expected: int64(9007199254740993)},
+		{name: "int64-text", sdsTypeCode: "Int64", value: "-9007199254740993", expected: int64(-9007199254740993)},
+		{name: "uint64-max", sdsTypeCode: "UInt64", value: json.Number("18446744073709551615"), expected: uint64(18446744073709551615)},
+		{name: "int32-number", sdsTypeCode: "Int32", value: json.Number("7"), expected: int32(7)},
+		{name: "int32-fraction", sdsTypeCode: "Int32", value: json.Number("7.0"), expected: int32(7)},
+		{name: "double-number", sdsTypeCode: "Double", value: json.Number("1.25"), expected: float64(1.25)},
+		{name: "boolean-number", sdsTypeCode: "Boolean", value: json.Number("1"), expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value := convertSdsValue(test.sdsTypeCode, test.value)
+			if !reflect.DeepEqual(value, test.expected) {
+				t.Errorf("FAILED: expected %v, got %v\n", test.expected, value)
+			}
+		})
+	}
+}
+
+func TestConvertSdsValueAllTypeCodes(t *testing.T) {
+	values := []interface{}{nil, float64(1), "1", "2022-06-04T00:00:00Z", "00:00:01", true, map[string]interface{}{"Major": 1}, []interface{}{float64(1)}, json.Number("9007199254740993")}
+
+	for code := 0; code < 1000; code++ {
+		var sdsTypeCode sds.SdsTypeCode
+		json.Unmarshal([]byte(strconv.Itoa(code)), &sdsTypeCode)
+		if sdsTypeCode == "" {
+			continue
+		}
+
+		t.Run(string(sdsTypeCode), func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("FAILED: expected no panic, got %v\n", r)
+				}
+			}()
+
+			field := data.NewField("Value", nil, createSdsValueList(sdsTypeCode))
+			for _, value := range values {
+				field.Append(convertSdsValue(sdsTypeCode, value))
+			}
+
+			// 2^53 + 1 is not representable as a float64, so it only survives a direct integer parse
+			_, baseTypeCode := splitSdsTypeCode(sdsTypeCode)
+			last := field.At(field.Len() - 1)
+			if baseTypeCode == "Int64" && reflect.Indirect(reflect.ValueOf(last)).Interface() != int64(9007199254740993) {
+				t.Errorf("FAILED: expected 9007199254740993, got %v\n", last)
+			}
+			if baseTypeCode == "UInt64" && reflect.Indirect(reflect.ValueOf(last)).Interface() != uint64(9007199254740993) {
+				t.Errorf("FAILED: expected 9007199254740993, got %v\n", last)
+			}
+		})
+	}
+}
+
+func TestStreamsDataQueryTypeCodes(t *testing.T) {
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(basePath+"/streams/StreamId1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{ "TypeId": "StreamType1", "Id": "StreamId1", "Name": "StreamName1" }`))
+	})
+
+	mux.HandleFunc(basePath+"/types/StreamType1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`
+		{
+			"Id": "StreamType1",
+			"SdsTypeCode": 1,
+			"Properties": [
+				{ "Id": "Timestamp", "IsKey": true, "SdsType": { "SdsTypeCode": 20 } },
+				{ "Id": "Status", "SdsType": { "SdsTypeCode": 6 } },
+				{ "Id": "Counter", "SdsType": { "SdsTypeCode": 11 } },
+				{
+					"Id": "State",
+					"SdsType": {
+						"SdsTypeCode": 609,
+						"Properties": [
+							{ "Id": "Stopped", "Value": 0 },
+							{ "Id": "Running", "Value": 1 }
+						]
+					}
+				}
+			]
+		}`))
+	})
+
+	mux.HandleFunc(basePath+"/streams/StreamId1/Data", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[
+			{ "Timestamp": "2022-06-04T00:00:00+02:00", "Status": 192, "Counter": 9007199254740993, "State": "Running" },
+			{ "Timestamp": "2022-06-05T00:00:00+02:00", "State": 0 }
+		]`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	offset := time.FixedZone("", 2*60*60)
+	expected := data.NewFrame("StreamName1",
+		data.NewField("Timestamp", nil, []time.Time{time.Date(2022, 6, 4, 0, 0, 0, 0, offset), time.Date(2022, 6, 5, 0, 0, 0, 0, offset)}),
+		data.NewField("Status", nil, []uint8{192, 0}),
+		data.NewField("Counter", nil, []int64{9007199254740993, 0}),
+		data.NewField("State", nil, []int32{1, 0}),
+	)
+
+	client := NewDataHubClient(server.URL, apiVersion, tenantId, "", "")
+	resp, err := StreamsDataQuery(context.Background(), &client, namespaceId, "token", "StreamId1", "", "")
+
+	if err != nil {
+		t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+	if !reflect.DeepEqual(resp, expected) {
+		t.Errorf("FAILED: expected %v, got %v\n", expected, resp)
+	}
+}
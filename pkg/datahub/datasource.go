@@ -3,58 +3,122 @@ package datahub
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
-	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/osisoft/sample-adh-grafana_backend_plugin-datasource/pkg/datahub/sds"
 )
 
 var (
 	_ backend.QueryDataHandler      = (*DataHubDataSource)(nil)
 	_ backend.CheckHealthHandler    = (*DataHubDataSource)(nil)
+	_ backend.CallResourceHandler   = (*DataHubDataSource)(nil)
 	_ instancemgmt.InstanceDisposer = (*DataHubDataSource)(nil)
 )
 
 type DataHubDataSource struct {
-	dataHubClient *DataHubClient
-	namespaceId   string
-	communityId   string
-	oauthPassThru bool
-	useCommunity  bool
+	dataHubClient     *DataHubClient
+	namespaceId       string
+	namespaceIds      []string
+	communityId       string
+	communityIds      []string
+	oauthPassThru     bool
+	useCommunity      bool
+	useEds            bool
+	maxFanOutStreams  int
+	fanOutConcurrency int
+	queryConcurrency  int
+	queryTimeout      time.Duration
 }
 
 type DataHubDataSourceOptions struct {
-	Resource      string `json:"resource"`
-	ApiVersion    string `json:"apiVersion"`
-	TenantId      string `json:"tenantId"`
-	NamespaceId   string `json:"namespaceId"`
-	UseCommunity  bool   `json:"useCommunity"`
-	CommunityId   string `json:"communityId"`
-	ClientId      string `json:"clientId"`
-	OauthPassThru bool   `json:"oauthPassThru"`
+	Type              string   `json:"type"`
+	EdsHost           string   `json:"edsHost"`
+	EdsPort           string   `json:"edsPort"`
+	Resource          string   `json:"resource"`
+	ApiVersion        string   `json:"apiVersion"`
+	TenantId          string   `json:"tenantId"`
+	NamespaceId       string   `json:"namespaceId"`
+	NamespaceIds      []string `json:"namespaceIds"`
+	UseCommunity      bool     `json:"useCommunity"`
+	CommunityId       string   `json:"communityId"`
+	CommunityIds      []string `json:"communityIds"`
+	ClientId          string   `json:"clientId"`
+	OauthPassThru     bool     `json:"oauthPassThru"`
+	PageSize          int      `json:"pageSize"`
+	MaxEvents         int      `json:"maxEvents"`
+	MaxFanOutStreams  int      `json:"maxFanOutStreams"`
+	FanOutConcurrency int      `json:"fanOutConcurrency"`
+	RetryMaxAttempts  int      `json:"retryMaxAttempts"`
+	RetryBackoffMs    int      `json:"retryBackoffMs"`
+	RetryMaxBackoffMs int      `json:"retryMaxBackoffMs"`
+	QueryTimeout      int      `json:"queryTimeout"`
+	QueryConcurrency  int      `json:"queryConcurrency"`
+	MetadataCacheTtl  int      `json:"metadataCacheTtl"`
+	MetadataCacheSize int      `json:"metadataCacheSize"`
+	DataCacheTtl      int      `json:"dataCacheTtl"`
+	DataCacheSize     int      `json:"dataCacheSize"`
+	RequestTimeout    int      `json:"requestTimeout"`
+	ProxyUrl          string   `json:"proxyUrl"`
+	TlsServerName     string   `json:"tlsServerName"`
 }
 
 type QueryModel struct {
-	Collection string `json:"collection"`
-	Query      string `json:"queryText"`
-	Id         string `json:"id"`
+	Collection   string   `json:"collection"`
+	Access       string   `json:"access"`
+	NamespaceId  string   `json:"namespaceId"`
+	CommunityId  string   `json:"communityId"`
+	Query        string   `json:"queryText"`
+	Id           string   `json:"id"`
+	Mode         string   `json:"mode"`
+	Count        int      `json:"count"`
+	SummaryTypes []string `json:"summaryTypes"`
+	SampleBy     string   `json:"sampleBy"`
+	StaleAfter   int      `json:"staleAfter"`
+	StreamViewId string   `json:"streamViewId"`
+	IncludeData  bool     `json:"includeData"`
+	Ids          []string `json:"ids"`
 }
 
+// Number of values or intervals to request when neither the query nor Grafana specify one.
+const defaultCount = 1000
+
+// Edge Data Store serves a single tenant and namespace, without authentication.
+const (
+	edsDefaultHost    = "localhost"
+	edsDefaultPort    = "5590"
+	edsApiVersion     = "v1"
+	edsTenantId       = "default"
+	edsNamespaceId    = "default"
+	edsDataSourceType = "EDS"
+)
+
+// Default limits for queries that read data for every stream matching a search.
+const (
+	defaultMaxFanOutStreams  = 100
+	defaultFanOutConcurrency = 8
+)
+
+// Default number of queries of a request that run at the same time.
+const defaultQueryConcurrency = 4
+
 type CheckHealthResponseBody struct {
 	Id string `json:"Id"`
 }
 
 // Creates a new datasource instance.
-func NewDataHubDataSource(settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+func NewDataHubDataSource(_ context.Context, settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
 	// Get JSON Data to read datasource settings
 	var options DataHubDataSourceOptions
 	err := json.Unmarshal(settings.JSONData, &options)
 	if err != nil {
-		log.DefaultLogger.Warn("error marshalling", "err", err)
+		logger.Warn("error marshalling", "err", err)
 		return nil, err
 	}
 
@@ -62,133 +126,630 @@ func NewDataHubDataSource(settings backend.DataSourceInstanceSettings) (instance
 	var secureData = settings.DecryptedSecureJSONData
 	clientSecret, _ := secureData["clientSecret"]
 
+	// Point Edge Data Store datasources at the local SDS endpoints, which need no token
+	useEds := strings.EqualFold(options.Type, edsDataSourceType)
+	if useEds {
+		options.Resource = getEdsResource(options.EdsHost, options.EdsPort)
+		options.ApiVersion = edsApiVersion
+		options.TenantId = edsTenantId
+		options.NamespaceId = edsNamespaceId
+		options.UseCommunity = false
+		options.OauthPassThru = false
+	}
+
+	// Build the HTTP client from the transport settings. The request timeout is applied to each request's
+	// context instead of the client, so that it surfaces as a deadline rather than a transport error.
+	httpClient, err := NewHttpClient(HttpClientOptions{
+		ProxyUrl:   options.ProxyUrl,
+		CACert:     secureData["tlsCACert"],
+		ClientCert: secureData["tlsClientCert"],
+		ClientKey:  secureData["tlsClientKey"],
+		ServerName: options.TlsServerName,
+	})
+	if err != nil {
+		logger.Warn("error creating http client", "err", err)
+		return nil, err
+	}
+
 	client := NewDataHubClient(options.Resource, options.ApiVersion, options.TenantId, options.ClientId, clientSecret)
+	client.SetHttpClient(httpClient)
+	client.SetPaging(options.PageSize, options.MaxEvents)
+	client.SetRetryPolicy(RetryPolicy{
+		MaxAttempts:    options.RetryMaxAttempts,
+		InitialBackoff: time.Duration(options.RetryBackoffMs) * time.Millisecond,
+		MaxBackoff:     time.Duration(options.RetryMaxBackoffMs) * time.Millisecond,
+	})
+	client.SetRequestTimeout(time.Duration(options.RequestTimeout) * time.Second)
+	client.SetMetadataCache(time.Duration(options.MetadataCacheTtl)*time.Second, options.MetadataCacheSize)
+	client.SetDataCache(time.Duration(options.DataCacheTtl)*time.Second, options.DataCacheSize)
+
+	maxFanOutStreams := options.MaxFanOutStreams
+	if maxFanOutStreams <= 0 {
+		maxFanOutStreams = defaultMaxFanOutStreams
+	}
+	fanOutConcurrency := options.FanOutConcurrency
+	if fanOutConcurrency <= 0 {
+		fanOutConcurrency = defaultFanOutConcurrency
+	}
+	queryConcurrency := options.QueryConcurrency
+	if queryConcurrency <= 0 {
+		queryConcurrency = defaultQueryConcurrency
+	}
+
+	// The configured namespace is the default for namespace queries, followed by the additional ones
+	namespaceIds := []string{}
+	for _, id := range append([]string{options.NamespaceId}, options.NamespaceIds...) {
+		if id != "" && !containsFold(namespaceIds, id) {
+			namespaceIds = append(namespaceIds, id)
+		}
+	}
+
+	// The configured community is the default for community queries, followed by the additional ones
+	communityIds := []string{}
+	for _, id := range append([]string{options.CommunityId}, options.CommunityIds...) {
+		if id != "" && !containsFold(communityIds, id) {
+			communityIds = append(communityIds, id)
+		}
+	}
+	communityId := ""
+	if len(communityIds) > 0 {
+		communityId = communityIds[0]
+	}
+
 	return &DataHubDataSource{
-		dataHubClient: &client,
-		namespaceId:   options.NamespaceId,
-		communityId:   options.CommunityId,
-		oauthPassThru: options.OauthPassThru,
-		useCommunity:  options.UseCommunity,
+		dataHubClient:     &client,
+		namespaceId:       options.NamespaceId,
+		namespaceIds:      namespaceIds,
+		communityId:       communityId,
+		communityIds:      communityIds,
+		oauthPassThru:     options.OauthPassThru,
+		useCommunity:      options.UseCommunity,
+		useEds:            useEds,
+		maxFanOutStreams:  maxFanOutStreams,
+		fanOutConcurrency: fanOutConcurrency,
+		queryConcurrency:  queryConcurrency,
+		queryTimeout:      time.Duration(options.QueryTimeout) * time.Second,
 	}, nil
 }
 
+// Builds the base URL of an Edge Data Store, defaulting to the local default port over HTTP.
+func getEdsResource(host string, port string) string {
+	if host == "" {
+		host = edsDefaultHost
+	}
+	if port == "" {
+		port = edsDefaultPort
+	}
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	return strings.TrimSuffix(host, "/") + ":" + port
+}
+
 // Dispose here tells plugin SDK that plugin wants to clean up resources when a new instance
 // created. As soon as datasource settings change detected by SDK old datasource instance will
 // be disposed and a new one will be created using the new instance factory function.
 func (d *DataHubDataSource) Dispose() {
 	// Clean up datasource instance resources.
+	d.dataHubClient.Close()
 }
 
 // Handles multiple queries and returns multiple responses.
 func (d *DataHubDataSource) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
-	log.DefaultLogger.Info("QueryData called", "request", req)
+	logger.Debug("QueryData called", "request", describeQueryDataRequest(req))
 
-	// retrieve token
-	var token string
-	if d.oauthPassThru {
-		token = req.Headers["Authorization"]
-		if len(token) == 0 {
-			return nil, fmt.Errorf("Unable to retrieve token")
-		}
-	} else {
-		var err error
-		token, err = GetClientToken(d.dataHubClient)
-		if err != nil {
-			log.DefaultLogger.Warn("Unable to retrieve token", err.Error())
-			return nil, err
+	// retrieve token, failing every query on its own rather than the whole request
+	token, err := d.getToken(ctx, req.Headers["Authorization"])
+	if err != nil {
+		response := backend.NewQueryDataResponse()
+		for _, q := range req.Queries {
+			response.Responses[q.RefID] = errorDataResponse(err)
 		}
+		return response, nil
 	}
 
+	// run the queries concurrently, each reporting its own failure so the others still render
+	responses := make([]backend.DataResponse, len(req.Queries))
+	slots := make(chan struct{}, d.queryConcurrency)
+	var wg sync.WaitGroup
+	for i := range req.Queries {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			responses[i] = d.runQuery(ctx, req.PluginContext, req.Queries[i], token)
+		}(i)
+	}
+	wg.Wait()
+
 	// create response struct
 	response := backend.NewQueryDataResponse()
 
-	// loop over queries and execute them individually.
-	for _, q := range req.Queries {
-		res, err := d.query(ctx, req.PluginContext, q, token)
+	// save the responses in a hashmap
+	// based on with RefID as identifier
+	for i, q := range req.Queries {
+		response.Responses[q.RefID] = responses[i]
+	}
 
-		if err != nil {
-			return nil, err
+	return response, nil
+}
+
+// Runs a single query, turning errors and panics into an error response for its RefID.
+func (d *DataHubDataSource) runQuery(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, token string) (res backend.DataResponse) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("Query panicked", "refId", query.RefID, "panic", fmt.Sprint(r))
+			res = backend.ErrDataResponseWithSource(backend.StatusInternal, backend.ErrorSourcePlugin, fmt.Sprintf("Query failed: %v", r))
 		}
+	}()
 
-		// save the response in a hashmap
-		// based on with RefID as identifier
-		response.Responses[q.RefID] = res
+	res, err := d.query(ctx, pCtx, query, token)
+	if err != nil {
+		logger.Warn("Query failed", "refId", query.RefID, "err", err)
+		return errorDataResponse(err)
 	}
+	return res
+}
 
-	return response, nil
+// Returned, wrapped, when OAuth pass-through is enabled but the request carries no user token.
+var ErrMissingAuthorization = errors.New("no Authorization header to pass through")
+
+// Returned, wrapped, when the identity server does not issue a client credential token.
+var ErrTokenUnavailable = errors.New("unable to retrieve token")
+
+// Retrieves the token to send to Data Hub: none for Edge Data Store, the user's own token when
+// OAuth pass-through is enabled, and otherwise the client credential token.
+func (d *DataHubDataSource) getToken(ctx context.Context, authorization string) (string, error) {
+	if d.useEds {
+		return "", nil
+	}
+
+	if d.oauthPassThru {
+		if len(authorization) == 0 {
+			return "", backend.DownstreamError(ErrMissingAuthorization)
+		}
+		return authorization, nil
+	}
+
+	token, err := GetClientToken(ctx, d.dataHubClient)
+	if err != nil {
+		logger.Warn("Unable to retrieve token", err.Error())
+		// timeouts keep their own status
+		if errors.Is(err, context.DeadlineExceeded) {
+			return "", err
+		}
+		return "", backend.DownstreamError(fmt.Errorf("%w: %s", ErrTokenUnavailable, err.Error()))
+	}
+	return token, nil
+}
+
+// Determines the namespace to read from, preferring the query's override over the configured namespace.
+func (d *DataHubDataSource) getNamespaceId(qm QueryModel) string {
+	if qm.NamespaceId != "" {
+		return qm.NamespaceId
+	}
+	return d.namespaceId
+}
+
+// Determines the namespace a namespace query reads from. Queries may only override the configured
+// namespace with one of the additional configured namespaces.
+func (d *DataHubDataSource) getNamespaceAccess(qm QueryModel) (string, error) {
+	if qm.NamespaceId == "" {
+		return d.namespaceId, nil
+	}
+	for _, id := range d.namespaceIds {
+		if strings.EqualFold(id, qm.NamespaceId) {
+			return id, nil
+		}
+	}
+	return "", backend.DownstreamErrorf("Namespace %s is not configured for this datasource", qm.NamespaceId)
+}
+
+// Determines whether the query reads community data and from which community, falling back to the
+// datasource's access mode and default community. Queries may only read configured communities.
+func (d *DataHubDataSource) getCommunityAccess(qm QueryModel) (bool, string, error) {
+	useCommunity := d.useCommunity
+	switch strings.ToLower(qm.Access) {
+	case "":
+	case "namespace":
+		useCommunity = false
+	case "community":
+		useCommunity = true
+	default:
+		return false, "", backend.DownstreamErrorf("Unknown access mode %s", qm.Access)
+	}
+
+	if !useCommunity {
+		return false, "", nil
+	}
+	if d.useEds {
+		return false, "", backend.DownstreamErrorf("Community data is not available for Edge Data Store")
+	}
+	if qm.CommunityId == "" {
+		if d.communityId == "" {
+			return false, "", backend.DownstreamErrorf("No community is configured for this datasource")
+		}
+		return true, d.communityId, nil
+	}
+	for _, id := range d.communityIds {
+		if strings.EqualFold(id, qm.CommunityId) {
+			return true, id, nil
+		}
+	}
+	return false, "", backend.DownstreamErrorf("Community %s is not configured for this datasource", qm.CommunityId)
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
 }
 
 // Handles the individual queries from QueryData.
-func (d *DataHubDataSource) query(_ context.Context, pCtx backend.PluginContext, query backend.DataQuery, token string) (backend.DataResponse, error) {
-	log.DefaultLogger.Info("Running query", "query", query)
+func (d *DataHubDataSource) query(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, token string) (backend.DataResponse, error) {
+	logger.Debug("Running query", "refId", query.RefID, "query", string(query.JSON))
 	response := backend.DataResponse{}
 
+	// bound the time spent on this query, on top of Grafana cancelling it
+	if d.queryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.queryTimeout)
+		defer cancel()
+	}
+
 	// unmarshal the JSON into our QueryModel.
 	var qm QueryModel
 
-	response.Error = json.Unmarshal(query.JSON, &qm)
-	if response.Error != nil {
-		return response, nil
+	err := json.Unmarshal(query.JSON, &qm)
+	if err != nil {
+		return response, backend.DownstreamError(err)
+	}
+
+	// determine whether to read namespace or community data
+	useCommunity, communityId, err := d.getCommunityAccess(qm)
+	if err != nil {
+		return response, err
+	}
+	qm.CommunityId = communityId
+
+	// namespace overrides are limited to the configured namespaces
+	if !useCommunity {
+		namespaceId, err := d.getNamespaceAccess(qm)
+		if err != nil {
+			return response, err
+		}
+		qm.NamespaceId = namespaceId
+	}
+
+	// a single selected stream is read on its own rather than joined
+	if len(qm.Ids) == 1 && qm.Id == "" {
+		qm.Id = qm.Ids[0]
 	}
 
 	// determine what type of query to use
 	frame := data.NewFrame("response")
-	var err error
-	if d.useCommunity {
-		if strings.EqualFold(qm.Collection, "streams") && qm.Id != "" {
-			log.DefaultLogger.Debug("Community stream data query")
-			frame, err = CommunityStreamsDataQuery(d.dataHubClient,
-				d.communityId,
-				token,
-				qm.Id,
-				query.TimeRange.From.Format(time.RFC3339),
-				query.TimeRange.To.Format(time.RFC3339))
-		} else if strings.EqualFold(qm.Collection, "streams") {
-			log.DefaultLogger.Debug("Community stream query")
-			frame, err = CommunityStreamsQuery(d.dataHubClient, d.communityId, token, qm.Query)
+	var frames []*data.Frame
+	startIndex := query.TimeRange.From.Format(time.RFC3339)
+	endIndex := query.TimeRange.To.Format(time.RFC3339)
+	if strings.EqualFold(qm.Collection, "streams") && len(qm.Ids) > 1 {
+		if useCommunity {
+			logger.Debug("Community multiple stream data query")
+			frames, err = d.fanOutDataQuery(qm.Ids, func(id string) (*data.Frame, error) {
+				streamQm := qm
+				streamQm.Id = id
+				return d.communityStreamsDataQuery(ctx, streamQm, query, token, startIndex, endIndex)
+			})
+		} else {
+			frame, err = d.streamsJoinDataQuery(ctx, qm, token, startIndex, endIndex)
 		}
-	} else {
-		if strings.EqualFold(qm.Collection, "streams") && qm.Id != "" {
-			log.DefaultLogger.Debug("Stream data query")
-			frame, err = StreamsDataQuery(d.dataHubClient,
-				d.namespaceId,
-				token,
-				qm.Id,
-				query.TimeRange.From.Format(time.RFC3339),
-				query.TimeRange.To.Format(time.RFC3339))
-		} else if strings.EqualFold(qm.Collection, "streams") {
-			log.DefaultLogger.Debug("Stream query")
-			frame, err = StreamsQuery(d.dataHubClient, d.namespaceId, token, qm.Query)
+	} else if strings.EqualFold(qm.Collection, "streams") && qm.Id != "" {
+		if useCommunity {
+			frame, err = d.communityStreamsDataQuery(ctx, qm, query, token, startIndex, endIndex)
+		} else {
+			frame, err = d.streamsDataQuery(ctx, qm, query, token, startIndex, endIndex)
 		}
+	} else if strings.EqualFold(qm.Collection, "streams") && qm.IncludeData {
+		if useCommunity {
+			frames, err = d.communityStreamsFanOutDataQuery(ctx, qm, query, token, startIndex, endIndex)
+		} else {
+			frames, err = d.streamsFanOutDataQuery(ctx, qm, query, token, startIndex, endIndex)
+		}
+	} else if strings.EqualFold(qm.Collection, "streams") {
+		if useCommunity {
+			logger.Debug("Community stream query")
+			frame, err = CommunityStreamsQuery(ctx, d.dataHubClient, qm.CommunityId, token, qm.Query)
+		} else {
+			logger.Debug("Stream query")
+			frame, err = StreamsQuery(ctx, d.dataHubClient, d.getNamespaceId(qm), token, qm.Query)
+		}
+	} else if strings.EqualFold(qm.Collection, "dataviews") && useCommunity {
+		err = backend.DownstreamErrorf("Data views are not available for community data")
+	} else if strings.EqualFold(qm.Collection, "dataviews") && d.useEds {
+		err = backend.DownstreamErrorf("Data views are not available for Edge Data Store")
+	} else if strings.EqualFold(qm.Collection, "dataviews") && qm.Id != "" {
+		logger.Debug("Data view data query")
+		frame, err = DataViewDataQuery(ctx, d.dataHubClient, d.getNamespaceId(qm), token, qm.Id, startIndex, endIndex, query.Interval)
+	} else if strings.EqualFold(qm.Collection, "dataviews") {
+		logger.Debug("Data view query")
+		frame, err = DataViewsQuery(ctx, d.dataHubClient, d.getNamespaceId(qm), token, qm.Query)
+	} else if strings.EqualFold(qm.Collection, "assets") && useCommunity {
+		err = backend.DownstreamErrorf("Assets are not available for community data")
+	} else if strings.EqualFold(qm.Collection, "assets") && d.useEds {
+		err = backend.DownstreamErrorf("Assets are not available for Edge Data Store")
+	} else if strings.EqualFold(qm.Collection, "assets") && qm.Id != "" {
+		logger.Debug("Asset data query")
+		frames, err = AssetsDataQuery(ctx, d.dataHubClient, d.getNamespaceId(qm), token, qm.Id, startIndex, endIndex, d.fanOutConcurrency)
+	} else if strings.EqualFold(qm.Collection, "assets") {
+		logger.Debug("Asset query")
+		frame, err = AssetsQuery(ctx, d.dataHubClient, d.getNamespaceId(qm), token, qm.Query)
 	}
 
 	// add the frames to the response.
-	response.Frames = append(response.Frames, frame)
+	if frames != nil {
+		response.Frames = append(response.Frames, frames...)
+	} else {
+		response.Frames = append(response.Frames, frame)
+	}
 
-	log.DefaultLogger.Info("We made it")
+	logger.Debug("Query completed", "refId", query.RefID)
 
 	return response, err
 }
 
+// Reads stream data from the query's namespace using the query's data mode.
+func (d *DataHubDataSource) streamsDataQuery(ctx context.Context, qm QueryModel, query backend.DataQuery, token string, startIndex string, endIndex string) (*data.Frame, error) {
+	if qm.StreamViewId != "" {
+		if qm.Mode != "" && !strings.EqualFold(qm.Mode, "raw") {
+			return nil, backend.DownstreamErrorf("Stream views are only supported for raw data queries")
+		}
+		logger.Debug("Stream view data query")
+		return StreamsStreamViewDataQuery(ctx, d.dataHubClient, d.getNamespaceId(qm), token, qm.Id, qm.StreamViewId, startIndex, endIndex)
+	}
+
+	switch strings.ToLower(qm.Mode) {
+	case "interpolated":
+		logger.Debug("Stream interpolated data query")
+		return StreamsInterpolatedDataQuery(ctx, d.dataHubClient, d.getNamespaceId(qm), token, qm.Id, startIndex, endIndex, getCount(qm, query))
+	case "summaries":
+		logger.Debug("Stream summaries data query")
+		return StreamsSummariesDataQuery(ctx, d.dataHubClient, d.getNamespaceId(qm), token, qm.Id, startIndex, endIndex, getCount(qm, query), qm.SummaryTypes)
+	case "sampled":
+		logger.Debug("Stream sampled data query")
+		return StreamsSampledDataQuery(ctx, d.dataHubClient, d.getNamespaceId(qm), token, qm.Id, startIndex, endIndex, getSampleIntervals(qm, query), qm.SampleBy)
+	case "last":
+		logger.Debug("Stream last value query")
+		frame, err := StreamsLastValueQuery(ctx, d.dataHubClient, d.getNamespaceId(qm), token, qm.Id)
+		if err != nil {
+			return nil, err
+		}
+		addStalenessNotice(frame, time.Duration(qm.StaleAfter)*time.Second)
+		return frame, nil
+	case "first":
+		logger.Debug("Stream first value query")
+		return StreamsFirstValueQuery(ctx, d.dataHubClient, d.getNamespaceId(qm), token, qm.Id)
+	default:
+		logger.Debug("Stream data query")
+		return StreamsDataQuery(ctx, d.dataHubClient, d.getNamespaceId(qm), token, qm.Id, startIndex, endIndex)
+	}
+}
+
+// Joins raw data for the query's namespace streams. SDS only joins raw data, so other data modes
+// and stream views are rejected.
+func (d *DataHubDataSource) streamsJoinDataQuery(ctx context.Context, qm QueryModel, token string, startIndex string, endIndex string) (*data.Frame, error) {
+	if qm.Mode != "" && !strings.EqualFold(qm.Mode, "raw") {
+		return nil, backend.DownstreamErrorf("Joining multiple streams is only supported for raw data queries")
+	}
+	if qm.StreamViewId != "" {
+		return nil, backend.DownstreamErrorf("Stream views are not supported when joining multiple streams")
+	}
+
+	logger.Debug("Stream join data query")
+	return StreamsJoinDataQuery(ctx, d.dataHubClient, d.getNamespaceId(qm), token, qm.Ids, startIndex, endIndex, d.fanOutConcurrency)
+}
+
+// Reads stream data from the query's community using the query's data mode.
+func (d *DataHubDataSource) communityStreamsDataQuery(ctx context.Context, qm QueryModel, query backend.DataQuery, token string, startIndex string, endIndex string) (*data.Frame, error) {
+	switch strings.ToLower(qm.Mode) {
+	case "interpolated":
+		logger.Debug("Community stream interpolated data query")
+		return CommunityStreamsInterpolatedDataQuery(ctx, d.dataHubClient, qm.CommunityId, token, qm.Id, startIndex, endIndex, getCount(qm, query))
+	case "summaries":
+		logger.Debug("Community stream summaries data query")
+		return CommunityStreamsSummariesDataQuery(ctx, d.dataHubClient, qm.CommunityId, token, qm.Id, startIndex, endIndex, getCount(qm, query), qm.SummaryTypes)
+	case "sampled":
+		logger.Debug("Community stream sampled data query")
+		return CommunityStreamsSampledDataQuery(ctx, d.dataHubClient, qm.CommunityId, token, qm.Id, startIndex, endIndex, getSampleIntervals(qm, query), qm.SampleBy)
+	case "last":
+		logger.Debug("Community stream last value query")
+		frame, err := CommunityStreamsLastValueQuery(ctx, d.dataHubClient, qm.CommunityId, token, qm.Id)
+		if err != nil {
+			return nil, err
+		}
+		addStalenessNotice(frame, time.Duration(qm.StaleAfter)*time.Second)
+		return frame, nil
+	case "first":
+		logger.Debug("Community stream first value query")
+		return CommunityStreamsFirstValueQuery(ctx, d.dataHubClient, qm.CommunityId, token, qm.Id)
+	default:
+		logger.Debug("Community stream data query")
+		return CommunityStreamsDataQuery(ctx, d.dataHubClient, qm.CommunityId, token, qm.Id, startIndex, endIndex)
+	}
+}
+
+// Reads data for every namespace stream matching the query text, one frame per stream.
+func (d *DataHubDataSource) streamsFanOutDataQuery(ctx context.Context, qm QueryModel, query backend.DataQuery, token string, startIndex string, endIndex string) ([]*data.Frame, error) {
+	logger.Debug("Stream fan-out data query")
+	streams, err := searchStreams(ctx, d.dataHubClient, d.getNamespaceId(qm), token, qm.Query, d.maxFanOutStreams+1)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(streams))
+	for i := 0; i < len(streams); i++ {
+		ids[i] = streams[i].Id
+	}
+
+	return d.fanOutDataQuery(ids, func(id string) (*data.Frame, error) {
+		streamQm := qm
+		streamQm.Id = id
+		return d.streamsDataQuery(ctx, streamQm, query, token, startIndex, endIndex)
+	})
+}
+
+// Reads data for every community stream matching the query text, one frame per stream.
+func (d *DataHubDataSource) communityStreamsFanOutDataQuery(ctx context.Context, qm QueryModel, query backend.DataQuery, token string, startIndex string, endIndex string) ([]*data.Frame, error) {
+	logger.Debug("Community stream fan-out data query")
+	streams, err := searchCommunityStreams(ctx, d.dataHubClient, qm.CommunityId, token, qm.Query, d.maxFanOutStreams+1)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(streams))
+	for i := 0; i < len(streams); i++ {
+		ids[i] = getCommunityStreamSelf(d.dataHubClient, streams[i])
+	}
+
+	return d.fanOutDataQuery(ids, func(id string) (*data.Frame, error) {
+		streamQm := qm
+		streamQm.Id = id
+		return d.communityStreamsDataQuery(ctx, streamQm, query, token, startIndex, endIndex)
+	})
+}
+
+// Fetches the streams concurrently, up to the configured maximum number of streams. Streams that fail
+// are reported in a notice rather than failing the query, unless every stream fails.
+func (d *DataHubDataSource) fanOutDataQuery(ids []string, fetch func(id string) (*data.Frame, error)) ([]*data.Frame, error) {
+	matched := len(ids)
+	if matched > d.maxFanOutStreams {
+		ids = ids[:d.maxFanOutStreams]
+	}
+
+	results, errs := fanOut(len(ids), d.fanOutConcurrency, func(i int) (*data.Frame, error) {
+		return fetch(ids[i])
+	})
+
+	var frames []*data.Frame
+	var notices []data.Notice
+	for i, err := range errs {
+		if err != nil {
+			logger.Warn("Error reading stream", err.Error())
+			notices = append(notices, data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("Stream %s could not be read: %s", ids[i], err.Error()),
+			})
+			continue
+		}
+		frames = append(frames, results[i])
+	}
+	if len(frames) == 0 && len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	if matched > len(ids) {
+		notices = append(notices, data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("More than %d streams matched the search; only the first %[1]d are shown", len(ids)),
+		})
+	}
+	if len(notices) > 0 && len(frames) > 0 {
+		frames[0].AppendNotices(notices...)
+	}
+
+	return frames, nil
+}
+
+// Determines the number of values or intervals to request, preferring an explicit count in the query.
+func getCount(qm QueryModel, query backend.DataQuery) int {
+	if qm.Count > 0 {
+		return qm.Count
+	}
+	if query.MaxDataPoints > 0 {
+		return int(query.MaxDataPoints)
+	}
+	return defaultCount
+}
+
+// Determines the number of sampling intervals so the sampled values fit the panel width. SDS returns
+// up to four values per interval (first, last, minimum and maximum), so the interval count is derived
+// from the query interval and capped at a quarter of the maximum data points.
+func getSampleIntervals(qm QueryModel, query backend.DataQuery) int {
+	if qm.Count > 0 {
+		return qm.Count
+	}
+
+	intervals := defaultCount / 4
+	if query.MaxDataPoints > 0 {
+		intervals = int(query.MaxDataPoints / 4)
+	}
+	if query.Interval > 0 {
+		byInterval := int(query.TimeRange.Duration() / query.Interval)
+		if byInterval > 0 && byInterval < intervals {
+			intervals = byInterval
+		}
+	}
+	if intervals < 1 {
+		intervals = 1
+	}
+	return intervals
+}
+
+// Warns on the frame when its most recent timestamp is older than the staleness threshold.
+// A threshold of zero disables the check.
+func addStalenessNotice(frame *data.Frame, staleAfter time.Duration) {
+	if staleAfter <= 0 || frame.Rows() == 0 {
+		return
+	}
+
+	for _, field := range frame.Fields {
+		var timestamp time.Time
+		switch value := field.At(frame.Rows() - 1).(type) {
+		case time.Time:
+			timestamp = value
+		case *time.Time:
+			if value == nil {
+				continue
+			}
+			timestamp = *value
+		default:
+			continue
+		}
+
+		age := time.Since(timestamp)
+		if age > staleAfter {
+			frame.AppendNotices(data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("Last value is stale: recorded %s ago, at %s", age.Round(time.Second), timestamp.Format(time.RFC3339)),
+			})
+		}
+		return
+	}
+}
+
 // Handles health checks sent from Grafana to the plugin.
-func (d *DataHubDataSource) CheckHealth(_ context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
-	log.DefaultLogger.Info("CheckHealth called", "request", req)
+func (d *DataHubDataSource) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	logger.Debug("CheckHealth called")
 
 	var status = backend.HealthStatusOk
 	var message = "Data source is working"
 
 	// Retrieve token
 	var token string
-	if d.oauthPassThru {
+	if d.useEds {
+		token = ""
+	} else if d.oauthPassThru {
 		return &backend.CheckHealthResult{
 			Status:  status,
 			Message: message,
 		}, nil
 	} else {
 		var err error
-		token, err = GetClientToken(d.dataHubClient)
+		token, err = GetClientToken(ctx, d.dataHubClient)
 		if err != nil {
-			log.DefaultLogger.Warn("Error unable to get token health check", err.Error())
+			logger.Warn("Error unable to get token health check", err.Error())
 			return &backend.CheckHealthResult{
 				Status:  backend.HealthStatusError,
 				Message: "Unable to retrieve token",
@@ -198,24 +759,37 @@ func (d *DataHubDataSource) CheckHealth(_ context.Context, req *backend.CheckHea
 
 	// Make a request to test the token
 	var path string
-	if d.useCommunity {
+	if d.useEds {
+		path = d.dataHubClient.resource + "/api/" + d.dataHubClient.apiVersion + "/tenants/" + d.dataHubClient.tenantId + "/namespaces/" + d.namespaceId + "/streams?count=1"
+	} else if d.useCommunity {
 		path = d.dataHubClient.resource + "/api/" + d.dataHubClient.apiVersion + "/tenants/" + d.dataHubClient.tenantId + "/communities/" + d.communityId
 	} else {
 		path = d.dataHubClient.resource + "/api/" + d.dataHubClient.apiVersion + "/tenants/" + d.dataHubClient.tenantId + "/namespaces/" + d.namespaceId
 	}
 
-	body, err := SdsRequest(d.dataHubClient, token, path, nil)
+	body, err := SdsRequest(ctx, d.dataHubClient, token, path, nil)
 	if err != nil {
-		log.DefaultLogger.Warn("Error test request health check", err.Error())
-		status = backend.HealthStatusError
+		logger.Warn("Error test request health check", err.Error())
 		message = "Invalid Configuration"
+		if errors.Is(err, ErrThrottled) {
+			message = "Throttled by Data Hub, try again later"
+		}
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: message,
+		}, nil
 	}
 
-	var responseJson CheckHealthResponseBody
-
-	err = json.Unmarshal(body, &responseJson)
+	// Edge Data Store has no namespace resource, so its check lists streams instead
+	if d.useEds {
+		var streams []sds.SdsStream
+		err = json.Unmarshal(body, &streams)
+	} else {
+		var responseJson CheckHealthResponseBody
+		err = json.Unmarshal(body, &responseJson)
+	}
 	if err != nil {
-		log.DefaultLogger.Warn("Error parsing resonse health check", err.Error())
+		logger.Warn("Error parsing resonse health check", err.Error())
 		status = backend.HealthStatusError
 		message = "Invalid Configuration"
 	}
@@ -0,0 +1,323 @@
+package datahub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func TestEdsCheckHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/tenants/default/namespaces/default/streams" || r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	serverUrl, _ := url.Parse(server.URL)
+	settings := backend.DataSourceInstanceSettings{
+		JSONData: []byte(`{ "type": "EDS", "edsHost": "` + serverUrl.Hostname() + `", "edsPort": "` + serverUrl.Port() + `" }`),
+	}
+
+	instance, err := NewDataHubDataSource(context.Background(), settings)
+	if err != nil {
+		t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+	ds := instance.(*DataHubDataSource)
+	defer ds.Dispose()
+
+	result, err := ds.CheckHealth(context.Background(), &backend.CheckHealthRequest{})
+	if err != nil {
+		t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+	if result.Status != backend.HealthStatusOk {
+		t.Errorf("FAILED: expected %v, got %v: %v\n", backend.HealthStatusOk, result.Status, result.Message)
+	}
+}
+
+func TestGetEdsResource(t *testing.T) {
+	tests := []struct {
+		host     string
+		port     string
+		expected string
+	}{
+		{host: "", port: "", expected: "http://localhost:5590"},
+		{host: "eds-node-1", port: "5591", expected: "http://eds-node-1:5591"},
+		{host: "https://eds-node-1/", port: "5590", expected: "https://eds-node-1:5590"},
+	}
+
+	for _, test := range tests {
+		if resource := getEdsResource(test.host, test.port); resource != test.expected {
+			t.Errorf("FAILED: expected %v, got %v\n", test.expected, resource)
+		}
+	}
+}
+
+func TestGetCommunityAccess(t *testing.T) {
+	type accessTests struct {
+		name                 string
+		dataSource           DataHubDataSource
+		qm                   QueryModel
+		expectedUseCommunity bool
+		expectedCommunityId  string
+		expectedError        bool
+	}
+
+	communityDataSource := DataHubDataSource{useCommunity: true, communityId: "community1", communityIds: []string{"community1", "supplier2"}}
+	namespaceDataSource := DataHubDataSource{useCommunity: false, communityId: "community1", communityIds: []string{"community1", "supplier2"}}
+
+	tests := []accessTests{
+		{name: "community-default", dataSource: communityDataSource, qm: QueryModel{}, expectedUseCommunity: true, expectedCommunityId: "community1"},
+		{name: "namespace-default", dataSource: namespaceDataSource, qm: QueryModel{}, expectedUseCommunity: false},
+		{name: "namespace-override", dataSource: communityDataSource, qm: QueryModel{Access: "namespace"}, expectedUseCommunity: false},
+		{name: "community-override", dataSource: namespaceDataSource, qm: QueryModel{Access: "community"}, expectedUseCommunity: true, expectedCommunityId: "community1"},
+		{name: "configured-community", dataSource: namespaceDataSource, qm: QueryModel{Access: "Community", CommunityId: "Supplier2"}, expectedUseCommunity: true, expectedCommunityId: "supplier2"},
+		{name: "unconfigured-community", dataSource: communityDataSource, qm: QueryModel{CommunityId: "other"}, expectedError: true},
+		{name: "no-community", dataSource: DataHubDataSource{}, qm: QueryModel{Access: "community"}, expectedError: true},
+		{name: "eds-community", dataSource: DataHubDataSource{useEds: true, communityId: "community1"}, qm: QueryModel{Access: "community"}, expectedError: true},
+		{name: "unknown-access", dataSource: communityDataSource, qm: QueryModel{Access: "tenant"}, expectedError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useCommunity, communityId, err := test.dataSource.getCommunityAccess(test.qm)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("Expected error FAILED: expected %v, got %v\n", test.expectedError, err)
+			}
+			if useCommunity != test.expectedUseCommunity || communityId != test.expectedCommunityId {
+				t.Errorf("FAILED: expected %v %v, got %v %v\n", test.expectedUseCommunity, test.expectedCommunityId, useCommunity, communityId)
+			}
+		})
+	}
+}
+
+func TestQueryDataIsolatesErrors(t *testing.T) {
+	type responseTests struct {
+		refId          string
+		expectedStatus backend.Status
+		expectedSource backend.ErrorSource
+		expectedError  bool
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/tenants/default/namespaces/default/streams" {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`[{ "Id": "StreamId1", "Name": "StreamName1" }]`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	ds := newEdsTestDataSource(t, server)
+	defer ds.Dispose()
+
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{ "collection": "streams", "queryText": "" }`)},
+			{RefID: "B", JSON: []byte(`{ "collection": "streams", "id": "Missing" }`)},
+			{RefID: "C", JSON: []byte(`{ "collection": "dataviews" }`)},
+			{RefID: "D", JSON: []byte(`{ "collection": `)},
+		},
+	}
+
+	tests := []responseTests{
+		{refId: "A", expectedError: false},
+		{refId: "B", expectedStatus: backend.StatusNotFound, expectedSource: backend.ErrorSourceDownstream, expectedError: true},
+		{refId: "C", expectedStatus: backend.StatusBadRequest, expectedSource: backend.ErrorSourceDownstream, expectedError: true},
+		{refId: "D", expectedStatus: backend.StatusBadRequest, expectedSource: backend.ErrorSourceDownstream, expectedError: true},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+
+	for _, test := range tests {
+		t.Run(test.refId, func(t *testing.T) {
+			res, ok := resp.Responses[test.refId]
+			if !ok {
+				t.Fatalf("FAILED: expected a response for %v\n", test.refId)
+			}
+			if (res.Error != nil) != test.expectedError {
+				t.Fatalf("Expected error FAILED: expected %v, got %v\n", test.expectedError, res.Error)
+			}
+			if res.Status != test.expectedStatus || res.ErrorSource != test.expectedSource {
+				t.Errorf("FAILED: expected %v %v, got %v %v\n", test.expectedStatus, test.expectedSource, res.Status, res.ErrorSource)
+			}
+		})
+	}
+
+	// a token that cannot be retrieved fails every query on its own, rather than the whole request
+	identity := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer identity.Close()
+
+	tokenTests := []struct {
+		name           string
+		json           string
+		expectedStatus backend.Status
+	}{
+		{name: "identity-failure", json: `{ "resource": "` + identity.URL + `", "clientId": "clientId" }`, expectedStatus: backend.StatusBadGateway},
+		{name: "missing-authorization", json: `{ "resource": "` + identity.URL + `", "oauthPassThru": true }`, expectedStatus: backend.StatusUnauthorized},
+	}
+
+	for _, test := range tokenTests {
+		t.Run(test.name, func(t *testing.T) {
+			instance, err := NewDataHubDataSource(context.Background(), backend.DataSourceInstanceSettings{JSONData: []byte(test.json)})
+			if err != nil {
+				t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+			}
+			ds := instance.(*DataHubDataSource)
+			defer ds.Dispose()
+
+			resp, err := ds.QueryData(context.Background(), req)
+			if err != nil || resp == nil {
+				t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+			}
+			for _, q := range req.Queries {
+				res, ok := resp.Responses[q.RefID]
+				if !ok || res.Error == nil {
+					t.Fatalf("FAILED: expected an error response for %v, got %v\n", q.RefID, res)
+				}
+				if res.Status != test.expectedStatus || res.ErrorSource != backend.ErrorSourceDownstream {
+					t.Errorf("FAILED: expected %v %v, got %v %v\n", test.expectedStatus, backend.ErrorSourceDownstream, res.Status, res.ErrorSource)
+				}
+			}
+		})
+	}
+}
+func TestQueryDataRequestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	serverUrl, _ := url.Parse(server.URL)
+	instance, err := NewDataHubDataSource(context.Background(), backend.DataSourceInstanceSettings{
+		JSONData: []byte(`{ "type": "EDS", "edsHost": "` + serverUrl.Hostname() + `", "edsPort": "` + serverUrl.Port() + `", "requestTimeout": 1 }`),
+	})
+	if err != nil {
+		t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+	ds := instance.(*DataHubDataSource)
+	defer ds.Dispose()
+
+	// a client timeout would race the request's deadline and fail with a transport error instead
+	if ds.dataHubClient.client.Timeout != 0 {
+		t.Errorf("FAILED: expected no client timeout, got %v\n", ds.dataHubClient.client.Timeout)
+	}
+
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{ "collection": "streams", "queryText": "" }`)},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+	res := resp.Responses["A"]
+	if res.Error == nil || res.Status != backend.StatusTimeout || res.ErrorSource != backend.ErrorSourceDownstream {
+		t.Errorf("FAILED: expected %v %v, got %v %v (%v)\n", backend.StatusTimeout, backend.ErrorSourceDownstream, res.Status, res.ErrorSource, res.Error)
+	}
+}
+
+func TestGetErrorStatusAndSource(t *testing.T) {
+	type errorTests struct {
+		name           string
+		err            error
+		expectedStatus backend.Status
+		expectedSource backend.ErrorSource
+	}
+
+	tests := []errorTests{
+		{name: "throttled", err: fmt.Errorf("%w: 429 Too Many Requests after 3 attempts", ErrThrottled), expectedStatus: backend.StatusTooManyRequests, expectedSource: backend.ErrorSourceDownstream},
+		{name: "not-found", err: &SdsError{StatusCode: http.StatusNotFound}, expectedStatus: backend.StatusNotFound, expectedSource: backend.ErrorSourceDownstream},
+		{name: "server-error", err: &SdsError{StatusCode: http.StatusInternalServerError}, expectedStatus: backend.StatusInternal, expectedSource: backend.ErrorSourceDownstream},
+		{name: "timeout", err: fmt.Errorf("request: %w", context.DeadlineExceeded), expectedStatus: backend.StatusTimeout, expectedSource: backend.ErrorSourceDownstream},
+		{name: "missing-authorization", err: backend.DownstreamError(ErrMissingAuthorization), expectedStatus: backend.StatusUnauthorized, expectedSource: backend.ErrorSourceDownstream},
+		{name: "token-unavailable", err: backend.DownstreamError(fmt.Errorf("%w: 500 Internal Server Error", ErrTokenUnavailable)), expectedStatus: backend.StatusBadGateway, expectedSource: backend.ErrorSourceDownstream},
+		{name: "invalid-stream-self", err: fmt.Errorf("%w: not a stream URL", ErrInvalidStreamSelf), expectedStatus: backend.StatusBadRequest, expectedSource: backend.ErrorSourceDownstream},
+		{name: "invalid-query", err: backend.DownstreamErrorf("Data views are not available for community data"), expectedStatus: backend.StatusBadRequest, expectedSource: backend.ErrorSourceDownstream},
+		{name: "plugin", err: errors.New("unexpected"), expectedStatus: backend.StatusInternal, expectedSource: backend.ErrorSourcePlugin},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status, source := getErrorStatusAndSource(test.err)
+			if status != test.expectedStatus || source != test.expectedSource {
+				t.Errorf("FAILED: expected %v %v, got %v %v\n", test.expectedStatus, test.expectedSource, status, source)
+			}
+		})
+	}
+}
+
+func TestLastValueStaleness(t *testing.T) {
+	type stalenessTests struct {
+		name            string
+		json            string
+		expectedNotices int
+	}
+
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	mux := newStreamsTestMux(basePath)
+
+	// the last event was recorded ten minutes ago
+	timestamp := time.Now().Add(-10 * time.Minute)
+	mux.HandleFunc(basePath+"/streams/StreamId1/Data/Last", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{ "Timestamp": "` + timestamp.Format(time.RFC3339) + `", "Value": 1 }`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := newEdsTestDataSource(t, server)
+	defer ds.Dispose()
+
+	tests := []stalenessTests{
+		{name: "stale", json: `{ "collection": "streams", "id": "StreamId1", "mode": "last", "staleAfter": 60 }`, expectedNotices: 1},
+		{name: "fresh", json: `{ "collection": "streams", "id": "StreamId1", "mode": "last", "staleAfter": 3600 }`, expectedNotices: 0},
+		{name: "disabled", json: `{ "collection": "streams", "id": "StreamId1", "mode": "last" }`, expectedNotices: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{JSON: []byte(test.json)}, "")
+			if err != nil {
+				t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+			}
+
+			var notices []data.Notice
+			if resp.Frames[0].Meta != nil {
+				notices = resp.Frames[0].Meta.Notices
+			}
+			if len(notices) != test.expectedNotices {
+				t.Errorf("FAILED: expected %v notices, got %v\n", test.expectedNotices, notices)
+			}
+			if test.expectedNotices > 0 && !strings.HasPrefix(notices[0].Text, "Last value is stale") {
+				t.Errorf("FAILED: expected a staleness notice, got %v\n", notices[0].Text)
+			}
+		})
+	}
+}
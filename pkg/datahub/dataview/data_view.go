@@ -0,0 +1,7 @@
+package dataview
+
+type DataView struct {
+	Id          string `json:"Id"`
+	Name        string `json:"Name"`
+	Description string `json:"Description"`
+}
@@ -0,0 +1,11 @@
+package dataview
+
+type DataViewTable struct {
+	Columns []DataViewColumn `json:"Columns"`
+	Rows    [][]interface{}  `json:"Rows"`
+}
+
+type DataViewColumn struct {
+	Name string `json:"Name"`
+	Type string `json:"Type"`
+}
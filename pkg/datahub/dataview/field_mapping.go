@@ -0,0 +1,16 @@
+package dataview
+
+type FieldMappings struct {
+	Items []FieldMapping `json:"Items"`
+}
+
+type FieldMapping struct {
+	Id           string        `json:"Id"`
+	DataMappings []DataMapping `json:"DataMappings"`
+}
+
+type DataMapping struct {
+	TargetId       string `json:"TargetId"`
+	TargetFieldKey string `json:"TargetFieldKey"`
+	TypeCode       string `json:"TypeCode"`
+}
@@ -0,0 +1,234 @@
+package datahub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/osisoft/sample-adh-grafana_backend_plugin-datasource/pkg/datahub/dataview"
+	"github.com/osisoft/sample-adh-grafana_backend_plugin-datasource/pkg/datahub/sds"
+)
+
+var nextLinkPattern = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+
+func DataViewsQuery(ctx context.Context, d *DataHubClient, namespaceId string, token string, query string) (*data.Frame, error) {
+	basePath := d.resource + "/api/" + d.apiVersion + "/tenants/" + url.QueryEscape(d.tenantId) + "/namespaces/" + url.QueryEscape(namespaceId)
+	path := (basePath + "/dataviews")
+
+	body, err := SdsRequest(ctx, d, token, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var dataViews []dataview.DataView
+
+	err = json.Unmarshal(body, &dataViews)
+	if err != nil {
+		logger.Warn("Error parsing json", err.Error())
+		logger.Warn(fmt.Sprint(string(body)))
+		return nil, err
+	}
+
+	// create a dataframe
+	frame := data.NewFrame("response")
+
+	// create property lists from data views list, keeping those whose id or name contain the query
+	ids := []string{}
+	names := []string{}
+	for i := 0; i < len(dataViews); i++ {
+		if query != "" &&
+			!strings.Contains(strings.ToLower(dataViews[i].Id), strings.ToLower(query)) &&
+			!strings.Contains(strings.ToLower(dataViews[i].Name), strings.ToLower(query)) {
+			continue
+		}
+		ids = append(ids, dataViews[i].Id)
+		names = append(names, dataViews[i].Name)
+	}
+
+	// add fields
+	frame.Fields = append(frame.Fields,
+		data.NewField("Id", nil, ids),
+		data.NewField("Name", nil, names),
+	)
+
+	return frame, nil
+}
+
+func DataViewDataQuery(ctx context.Context, d *DataHubClient, namespaceId string, token string, id string, startIndex string, endIndex string, interval time.Duration) (*data.Frame, error) {
+	basePath := d.resource + "/api/" + d.apiVersion + "/tenants/" + url.QueryEscape(d.tenantId) + "/namespaces/" + url.QueryEscape(namespaceId)
+	path := (basePath + "/dataviews/" + url.QueryEscape(id) + "/data/interpolated?startIndex=" + url.QueryEscape(startIndex) + "&endIndex=" + url.QueryEscape(endIndex) + "&interval=" + url.QueryEscape(formatTimeSpan(interval)) + "&form=table")
+
+	var columns []dataview.DataViewColumn
+	var rows [][]interface{}
+	truncated := false
+
+	// follow next links until the data view has returned the whole time range
+	for path != "" {
+		body, headers, err := sdsRequestWithHeaders(ctx, d, token, path, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var table dataview.DataViewTable
+		err = sds.UnmarshalData(body, &table)
+		if err != nil {
+			logger.Warn("Error parsing json", err.Error())
+			logger.Warn(fmt.Sprint(string(body)))
+			return nil, err
+		}
+
+		if columns == nil {
+			columns = table.Columns
+		}
+		rows = append(rows, table.Rows...)
+
+		path = getNextLink(headers.Values("Link"))
+		if path != "" && len(rows) >= d.maxEvents {
+			rows = rows[:d.maxEvents]
+			truncated = true
+			break
+		}
+	}
+
+	columnTypes := getDataViewColumnTypes(ctx, d, basePath, token, id, columns)
+	frame := createDataFrameFromDataViewTable(id, columns, columnTypes, rows)
+	if truncated {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("Data truncated to the first %d rows; narrow the time range or widen the interval to see the complete window", d.maxEvents),
+		})
+	}
+
+	return frame, nil
+}
+
+// Finds the URL of the next page in Link response headers.
+func getNextLink(links []string) string {
+	for _, link := range links {
+		if match := nextLinkPattern.FindStringSubmatch(link); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// Formats a duration as a .NET TimeSpan, which is what data views expect for intervals.
+func formatTimeSpan(interval time.Duration) string {
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	days := int64(interval / (24 * time.Hour))
+	hours := int64(interval/time.Hour) % 24
+	minutes := int64(interval/time.Minute) % 60
+	seconds := int64(interval/time.Second) % 60
+
+	if days > 0 {
+		return fmt.Sprintf("%d.%02d:%02d:%02d", days, hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
+}
+
+// Finds the SDS type of each data view column. Table columns only report a type code, so the members
+// of enumeration columns are read from the stream types behind the data view's resolved field
+// mappings. Columns whose members can't be found keep just their type code.
+func getDataViewColumnTypes(ctx context.Context, d *DataHubClient, basePath string, token string, id string, columns []dataview.DataViewColumn) []sds.SdsType {
+	columnTypes := make([]sds.SdsType, len(columns))
+	hasEnum := false
+	for i := 0; i < len(columns); i++ {
+		columnTypes[i] = sds.SdsType{SdsTypeCode: sds.SdsTypeCode(columns[i].Type)}
+		if strings.HasSuffix(columns[i].Type, "Enum") {
+			hasEnum = true
+		}
+	}
+	if !hasEnum {
+		return columnTypes
+	}
+
+	path := (basePath + "/dataviews/" + url.QueryEscape(id) + "/resolved/fieldmappings")
+	body, err := sdsMetadataRequest(ctx, d, token, path, nil)
+	if err != nil {
+		logger.Warn("Error reading data view field mappings", err.Error())
+		return columnTypes
+	}
+
+	var mappings dataview.FieldMappings
+	err = json.Unmarshal(body, &mappings)
+	if err != nil || len(mappings.Items) != len(columns) {
+		logger.Warn("Error parsing data view field mappings")
+		return columnTypes
+	}
+
+	for i := 0; i < len(columns); i++ {
+		if !strings.HasSuffix(columns[i].Type, "Enum") {
+			continue
+		}
+		for _, mapping := range mappings.Items[i].DataMappings {
+			if mapping.TargetId == "" {
+				continue
+			}
+			_, sdsType, err := getStreamAndType(ctx, d, basePath, token, mapping.TargetId)
+			if err != nil {
+				logger.Warn("Error reading data view stream type", err.Error())
+				continue
+			}
+			for _, property := range sdsType.Properties {
+				if property.Id == mapping.TargetFieldKey {
+					columnTypes[i].Properties = property.SdsType.Properties
+				}
+			}
+			if columnTypes[i].Properties != nil {
+				break
+			}
+		}
+	}
+
+	return columnTypes
+}
+
+func createDataFrameFromDataViewTable(dataFrameName string, columns []dataview.DataViewColumn, columnTypes []sds.SdsType, rows [][]interface{}) *data.Frame {
+	// create a dataframe
+	frame := data.NewFrame(dataFrameName)
+
+	// create columns in dataframe, nullable since data views fill gaps with nulls
+	nullableTypes := make([]sds.SdsType, len(columns))
+	for i := 0; i < len(columns); i++ {
+		nullableTypes[i] = columnTypes[i]
+		nullableTypes[i].SdsTypeCode = getNullableSdsTypeCode(sds.SdsTypeCode(columns[i].Type))
+		frame.Fields = append(frame.Fields,
+			data.NewField(columns[i].Name, nil, createSdsValueList(nullableTypes[i].SdsTypeCode)))
+	}
+
+	// add data to rows
+	for i := 0; i < len(rows); i++ {
+		row := make([]interface{}, len(columns))
+		for j := 0; j < len(columns); j++ {
+			var value interface{}
+			if j < len(rows[i]) {
+				value = rows[i][j]
+			}
+			row[j] = convertSdsPropertyValue(nullableTypes[j], value)
+		}
+		frame.AppendRow(row...)
+	}
+
+	return frame
+}
+
+func getNullableSdsTypeCode(sdsTypeCode sds.SdsTypeCode) sds.SdsTypeCode {
+	nullable, baseTypeCode := splitSdsTypeCode(sdsTypeCode)
+	if nullable {
+		return sdsTypeCode
+	}
+	switch baseTypeCode {
+	case "DateTime", "DateTimeOffset", "Boolean", "SByte", "Byte", "Int16", "UInt16", "Int32", "UInt32", "Int64", "UInt64", "Single", "Double", "Decimal", "TimeSpan":
+		return "Nullable" + sdsTypeCode
+	default:
+		return sdsTypeCode
+	}
+}
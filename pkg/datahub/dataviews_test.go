@@ -0,0 +1,203 @@
+package datahub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func TestDataViewsQuery(t *testing.T) {
+	tests := []Tests{
+		{
+			name: "data-views-query",
+			server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`[
+					{
+						"Id": "DataViewId1",
+						"Name": "Line 1 Temperatures",
+						"Description": ""
+					},
+					{
+						"Id": "DataViewId2",
+						"Name": "Line 2 Pressures",
+						"Description": ""
+					}
+				]`))
+			})),
+			response: data.NewFrame("response",
+				data.NewField("Id", nil, []string{"DataViewId1"}),
+				data.NewField("Name", nil, []string{"Line 1 Temperatures"}),
+			),
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer test.server.Close()
+
+			client := NewDataHubClient(test.server.URL, apiVersion, tenantId, "", "")
+			resp, err := DataViewsQuery(context.Background(), &client, namespaceId, "token", "temperatures")
+
+			if !reflect.DeepEqual(resp, test.response) {
+				t.Errorf("FAILED: expected %v, got %v\n", test.response, resp)
+			}
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Expected error FAILED: expected %v, got %v\n", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestDataViewDataQuery(t *testing.T) {
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	mux.HandleFunc(basePath+"/dataviews/DataViewId1/data/interpolated", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("interval") != "01:00:00" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("continuationToken") == "" {
+			w.Header().Add("Link", `<`+server.URL+r.URL.Path+`?`+r.URL.RawQuery+`&continuationToken=page2>; rel="next"`)
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+				"Columns": [
+					{ "Name": "Timestamp", "Type": "DateTime" },
+					{ "Name": "Pump12.Temperature", "Type": "Double" }
+				],
+				"Rows": [
+					[ "2022-06-04T00:00:00Z", 20.5 ]
+				]
+			}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"Columns": [
+				{ "Name": "Timestamp", "Type": "DateTime" },
+				{ "Name": "Pump12.Temperature", "Type": "Double" }
+			],
+			"Rows": [
+				[ "2022-06-04T01:00:00Z", null ]
+			]
+		}`))
+	})
+
+	first := time.Date(2022, 6, 4, 0, 0, 0, 0, time.UTC)
+	second := time.Date(2022, 6, 4, 1, 0, 0, 0, time.UTC)
+	value := 20.5
+
+	tests := []Tests{
+		{
+			name:   "data-view-data-query",
+			server: server,
+			response: data.NewFrame("DataViewId1",
+				data.NewField("Timestamp", nil, []*time.Time{&first, &second}),
+				data.NewField("Pump12.Temperature", nil, []*float64{&value, nil}),
+			),
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer test.server.Close()
+
+			client := NewDataHubClient(test.server.URL, apiVersion, tenantId, "", "")
+			resp, err := DataViewDataQuery(context.Background(), &client, namespaceId, "token", "DataViewId1", "", "", time.Hour)
+
+			if !reflect.DeepEqual(resp, test.response) {
+				t.Errorf("FAILED: expected %v, got %v\n", test.response, resp)
+			}
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Expected error FAILED: expected %v, got %v\n", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestDataViewDataQueryEnum(t *testing.T) {
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(basePath+"/dataviews/DataViewId1/data/interpolated", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"Columns": [
+				{ "Name": "Timestamp", "Type": "DateTime" },
+				{ "Name": "Pump12.State", "Type": "Int32Enum" }
+			],
+			"Rows": [
+				[ "2022-06-04T00:00:00Z", "Running" ],
+				[ "2022-06-04T01:00:00Z", null ]
+			]
+		}`))
+	})
+
+	mux.HandleFunc(basePath+"/dataviews/DataViewId1/resolved/fieldmappings", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"Items": [
+				{ "Id": "Timestamp", "DataMappings": [ { "TargetId": "Pump12", "TargetFieldKey": "Timestamp", "TypeCode": "DateTime" } ] },
+				{ "Id": "Pump12.State", "DataMappings": [ { "TargetId": "Pump12", "TargetFieldKey": "State", "TypeCode": "Int32Enum" } ] }
+			]
+		}`))
+	})
+
+	mux.HandleFunc(basePath+"/streams/Pump12", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{ "TypeId": "PumpType", "Id": "Pump12", "Name": "Pump12" }`))
+	})
+
+	mux.HandleFunc(basePath+"/types/PumpType", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`
+		{
+			"Id": "PumpType",
+			"SdsTypeCode": 1,
+			"Properties": [
+				{ "Id": "Timestamp", "IsKey": true, "SdsType": { "SdsTypeCode": 16 } },
+				{
+					"Id": "State",
+					"SdsType": {
+						"SdsTypeCode": 609,
+						"Properties": [
+							{ "Id": "Stopped", "Value": 0 },
+							{ "Id": "Running", "Value": 1 }
+						]
+					}
+				}
+			]
+		}`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	first := time.Date(2022, 6, 4, 0, 0, 0, 0, time.UTC)
+	second := time.Date(2022, 6, 4, 1, 0, 0, 0, time.UTC)
+	running := int32(1)
+	expected := data.NewFrame("DataViewId1",
+		data.NewField("Timestamp", nil, []*time.Time{&first, &second}),
+		data.NewField("Pump12.State", nil, []*int32{&running, nil}),
+	)
+
+	client := NewDataHubClient(server.URL, apiVersion, tenantId, "", "")
+	resp, err := DataViewDataQuery(context.Background(), &client, namespaceId, "token", "DataViewId1", "", "", time.Hour)
+
+	if err != nil {
+		t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+	if !reflect.DeepEqual(resp, expected) {
+		t.Errorf("FAILED: expected %v, got %v\n", expected, resp)
+	}
+}
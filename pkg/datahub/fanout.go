@@ -0,0 +1,38 @@
+package datahub
+
+import (
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Runs fetch for every index in [0, count) with at most concurrency fetches in flight, returning the
+// frames and errors in index order. A failed fetch leaves a nil frame and does not stop the others.
+func fanOut(count int, concurrency int, fetch func(i int) (*data.Frame, error)) ([]*data.Frame, []error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	frames := make([]*data.Frame, count)
+	errs := make([]error, count)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < concurrency && w < count; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				frames[i], errs[i] = fetch(i)
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return frames, errs
+}
@@ -0,0 +1,169 @@
+package datahub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func TestFanOut(t *testing.T) {
+	var inFlight, maxInFlight int32
+	frames, errs := fanOut(10, 3, func(i int) (*data.Frame, error) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			observed := atomic.LoadInt32(&maxInFlight)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxInFlight, observed, current) {
+				break
+			}
+		}
+		return data.NewFrame(strconv.Itoa(i)), nil
+	})
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+		}
+	}
+	names := make([]string, len(frames))
+	for i, frame := range frames {
+		names[i] = frame.Name
+	}
+	expected := []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("FAILED: expected %v, got %v\n", expected, names)
+	}
+	if maxInFlight > 3 {
+		t.Errorf("FAILED: expected at most 3 concurrent fetches, got %d\n", maxInFlight)
+	}
+}
+
+func TestFanOutError(t *testing.T) {
+	expectedError := errors.New("stream not found")
+	frames, errs := fanOut(5, 2, func(i int) (*data.Frame, error) {
+		if i == 1 {
+			return nil, expectedError
+		}
+		return data.NewFrame(strconv.Itoa(i)), nil
+	})
+
+	for i := range frames {
+		if i == 1 {
+			if frames[i] != nil || !errors.Is(errs[i], expectedError) {
+				t.Errorf("Expected error FAILED: expected %v, got %v %v\n", expectedError, frames[i], errs[i])
+			}
+			continue
+		}
+		if frames[i] == nil || frames[i].Name != strconv.Itoa(i) || errs[i] != nil {
+			t.Errorf("FAILED: expected frame %v, got %v %v\n", i, frames[i], errs[i])
+		}
+	}
+}
+
+func TestStreamsFanOutDataQuery(t *testing.T) {
+	type fanOutTests struct {
+		name             string
+		maxFanOutStreams int
+		expectedNames    []string
+		expectedNotices  []string
+	}
+
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	mux := newStreamsTestMux(basePath)
+
+	var counts []string
+	var mu sync.Mutex
+	mux.HandleFunc(basePath+"/streams", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		counts = append(counts, r.URL.Query().Get("count"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[{ "Id": "StreamId1", "Name": "StreamName1" }, { "Id": "Missing", "Name": "Missing" }, { "Id": "StreamId1", "Name": "StreamName1" }]`))
+	})
+
+	mux.HandleFunc(basePath+"/streams/StreamId1/Data", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[{ "Timestamp": "2022-06-04T00:00:00Z", "Value": 1 }]`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []fanOutTests{
+		{
+			name:             "partial-failure",
+			maxFanOutStreams: 10,
+			expectedNames:    []string{"StreamName1", "StreamName1"},
+			expectedNotices:  []string{"Stream Missing could not be read: "},
+		},
+		{
+			name:             "truncated",
+			maxFanOutStreams: 2,
+			expectedNames:    []string{"StreamName1"},
+			expectedNotices:  []string{"Stream Missing could not be read: ", "More than 2 streams matched the search; only the first 2 are shown"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ds := newEdsTestDataSource(t, server)
+			defer ds.Dispose()
+			ds.maxFanOutStreams = test.maxFanOutStreams
+
+			qm := QueryModel{Collection: "streams", IncludeData: true}
+			frames, err := ds.streamsFanOutDataQuery(context.Background(), qm, backend.DataQuery{}, "", "", "")
+			if err != nil {
+				t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+			}
+
+			names := make([]string, len(frames))
+			for i, frame := range frames {
+				names[i] = frame.Name
+			}
+			if !reflect.DeepEqual(names, test.expectedNames) {
+				t.Errorf("FAILED: expected %v, got %v\n", test.expectedNames, names)
+			}
+
+			notices := frames[0].Meta.Notices
+			if len(notices) != len(test.expectedNotices) {
+				t.Fatalf("FAILED: expected %v, got %v\n", test.expectedNotices, notices)
+			}
+			for i, notice := range notices {
+				if !strings.HasPrefix(notice.Text, test.expectedNotices[i]) {
+					t.Errorf("FAILED: expected %v, got %v\n", test.expectedNotices[i], notice.Text)
+				}
+			}
+		})
+	}
+
+	expectedCounts := []string{"11", "3"}
+	if !reflect.DeepEqual(counts, expectedCounts) {
+		t.Errorf("FAILED: expected search counts %v, got %v\n", expectedCounts, counts)
+	}
+}
+
+func TestFanOutDataQueryAllFailed(t *testing.T) {
+	expectedError := errors.New("stream not found")
+	ds := &DataHubDataSource{maxFanOutStreams: 10, fanOutConcurrency: 2}
+
+	frames, err := ds.fanOutDataQuery([]string{"StreamId1", "StreamId2"}, func(id string) (*data.Frame, error) {
+		return nil, expectedError
+	})
+
+	if frames != nil {
+		t.Errorf("FAILED: expected %v, got %v\n", nil, frames)
+	}
+	if !errors.Is(err, expectedError) {
+		t.Errorf("Expected error FAILED: expected %v, got %v\n", expectedError, err)
+	}
+}
@@ -0,0 +1,77 @@
+package datahub
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Settings for the HTTP client used to reach Data Hub or Edge Data Store.
+type HttpClientOptions struct {
+	// Proxy for outgoing requests. When empty, the proxy environment variables are used.
+	ProxyUrl string
+	// PEM encoded certificates trusted in addition to the system roots.
+	CACert string
+	// PEM encoded client certificate and key for mutual TLS.
+	ClientCert string
+	ClientKey  string
+	// Name used to verify the server certificate, when it differs from the host name.
+	ServerName string
+}
+
+func NewHttpClient(options HttpClientOptions) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		ServerName: options.ServerName,
+	}
+
+	if options.CACert != "" {
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil || rootCAs == nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM([]byte(options.CACert)) {
+			return nil, fmt.Errorf("Unable to parse CA certificate")
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if options.ClientCert != "" || options.ClientKey != "" {
+		certificate, err := tls.X509KeyPair([]byte(options.ClientCert), []byte(options.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if options.ProxyUrl != "" {
+		// the proxy URL may hold credentials, so it is left out of the error
+		proxyUrl, err := url.Parse(options.ProxyUrl)
+		if err != nil || proxyUrl.Host == "" {
+			return nil, fmt.Errorf("Invalid proxy URL")
+		}
+		proxy = http.ProxyURL(proxyUrl)
+	}
+
+	transport := &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	return &http.Client{
+		Transport: transport,
+	}, nil
+}
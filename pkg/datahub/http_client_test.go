@@ -0,0 +1,61 @@
+package datahub
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewHttpClientCACert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	caCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	// the test server certificate is not trusted by default
+	client, err := NewHttpClient(HttpClientOptions{})
+	if err != nil {
+		t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+	if _, err := client.Get(server.URL); err == nil {
+		t.Errorf("Expected error FAILED: expected an unknown authority error, got nil\n")
+	}
+
+	client, err = NewHttpClient(HttpClientOptions{CACert: caCert, ServerName: "example.com"})
+	if err != nil {
+		t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+	resp.Body.Close()
+}
+
+func TestNewHttpClientInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options HttpClientOptions
+	}{
+		{name: "invalid-ca-cert", options: HttpClientOptions{CACert: "not a certificate"}},
+		{name: "invalid-client-cert", options: HttpClientOptions{ClientCert: "not a certificate", ClientKey: "not a key"}},
+		{name: "invalid-proxy-url", options: HttpClientOptions{ProxyUrl: "proxy:8080:8080"}},
+		{name: "invalid-proxy-url-credentials", options: HttpClientOptions{ProxyUrl: "user:secret@proxy:8080"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, err := NewHttpClient(test.options)
+			if client != nil || err == nil {
+				t.Fatalf("Expected error FAILED: expected an error, got %v\n", err)
+			}
+			if strings.Contains(err.Error(), "secret") {
+				t.Errorf("FAILED: expected the error to leave out credentials, got %v\n", err)
+			}
+		})
+	}
+}
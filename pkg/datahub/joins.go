@@ -0,0 +1,129 @@
+package datahub
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/osisoft/sample-adh-grafana_backend_plugin-datasource/pkg/datahub/sds"
+)
+
+// Joins the raw data of the streams, reading their metadata with at most concurrency requests in flight.
+func StreamsJoinDataQuery(ctx context.Context, d *DataHubClient, namespaceId string, token string, ids []string, startIndex string, endIndex string, concurrency int) (*data.Frame, error) {
+	basePath := d.resource + "/api/" + d.apiVersion + "/tenants/" + url.QueryEscape(d.tenantId) + "/namespaces/" + url.QueryEscape(namespaceId)
+
+	streams := make([]sds.SdsStream, len(ids))
+	sdsTypes := make([]sds.SdsType, len(ids))
+	_, errs := fanOut(len(ids), concurrency, func(i int) (*data.Frame, error) {
+		var err error
+		streams[i], sdsTypes[i], err = getStreamAndType(ctx, d, basePath, token, ids[i])
+		return nil, err
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// get joined data
+	escapedIds := make([]string, len(ids))
+	for i, id := range ids {
+		escapedIds[i] = url.QueryEscape(id)
+	}
+	path := (basePath + "/Bulk/Streams/Data/Joins?streams=" + strings.Join(escapedIds, ",") + "&joinMode=outer&startIndex=" + url.QueryEscape(startIndex) + "&endIndex=" + url.QueryEscape(endIndex))
+
+	var sdsJoinedData [][]map[string]interface{}
+	truncated, err := readSdsPages(ctx, d, token, path, nil, func(body []byte) (int, string, bool, error) {
+		var page sds.SdsJoinResultPage
+		if err := sds.UnmarshalData(body, &page); err != nil {
+			return 0, "", false, err
+		}
+		sdsJoinedData = append(sdsJoinedData, page.Results...)
+		return len(page.Results), page.ContinuationToken, page.Paged, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	frame, err := createDataFrameFromSdsJoinedData(streams, sdsTypes, sdsJoinedData)
+	if err != nil {
+		return nil, err
+	}
+	if truncated {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("Data truncated to the first %d rows; narrow the time range to see the complete window", len(sdsJoinedData)),
+		})
+	}
+
+	return frame, nil
+}
+
+func createDataFrameFromSdsJoinedData(streams []sds.SdsStream, sdsTypes []sds.SdsType, sdsJoinedData [][]map[string]interface{}) (*data.Frame, error) {
+	// create a dataframe
+	frame := data.NewFrame("response")
+
+	// find each stream's index property; the first stream's index labels every row
+	keyProperties := make([]sds.SdsTypeProperty, len(sdsTypes))
+	for i, sdsType := range sdsTypes {
+		found := false
+		for _, property := range sdsType.Properties {
+			if property.IsKey {
+				keyProperties[i] = property
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Type %s has no key property", sdsType.Id)
+		}
+	}
+	frame.Fields = append(frame.Fields,
+		data.NewField(keyProperties[0].Id, nil, createSdsValueList(keyProperties[0].SdsType.SdsTypeCode)))
+
+	// create columns in dataframe, one per stream and property, nullable since joined streams may lack values
+	type joinColumn struct {
+		stream     int
+		propertyId string
+		sdsType    sds.SdsType
+	}
+	var columns []joinColumn
+	for i, sdsType := range sdsTypes {
+		for _, property := range sdsType.Properties {
+			if property.IsKey {
+				continue
+			}
+			column := joinColumn{i, property.Id, property.SdsType}
+			column.sdsType.SdsTypeCode = getNullableSdsTypeCode(property.SdsType.SdsTypeCode)
+			columns = append(columns, column)
+			frame.Fields = append(frame.Fields,
+				data.NewField(streams[i].Name+"."+property.Id, nil, createSdsValueList(column.sdsType.SdsTypeCode)))
+		}
+	}
+
+	// add data to rows
+	for _, joinedRow := range sdsJoinedData {
+		var index interface{}
+		for i, event := range joinedRow {
+			if event != nil && i < len(keyProperties) {
+				index = event[keyProperties[i].Id]
+				break
+			}
+		}
+
+		row := make([]interface{}, len(columns)+1)
+		row[0] = convertSdsPropertyValue(keyProperties[0].SdsType, index)
+		for j, column := range columns {
+			var value interface{}
+			if column.stream < len(joinedRow) && joinedRow[column.stream] != nil {
+				value = joinedRow[column.stream][column.propertyId]
+			}
+			row[j+1] = convertSdsPropertyValue(column.sdsType, value)
+		}
+		frame.AppendRow(row...)
+	}
+
+	return frame, nil
+}
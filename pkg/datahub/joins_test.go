@@ -0,0 +1,238 @@
+package datahub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func TestStreamsJoinDataQuery(t *testing.T) {
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	mux := newStreamsTestMux(basePath)
+
+	mux.HandleFunc(basePath+"/streams/StreamId2", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`
+			{
+				"TypeId": "StreamType1",
+				"Id": "StreamId2",
+				"Name": "StreamName2"
+			}`))
+	})
+
+	mux.HandleFunc(basePath+"/Bulk/Streams/Data/Joins", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("streams") != "StreamId1,StreamId2" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[
+			[
+				{ "Timestamp": "2022-06-04T00:00:00Z", "Value": 1 },
+				{ "Timestamp": "2022-06-04T00:00:00Z", "Value": 2 }
+			],
+			[
+				null,
+				{ "Timestamp": "2022-06-05T00:00:00Z", "Value": 3 }
+			]
+		]`))
+	})
+
+	values := []float64{1, 2, 3}
+
+	tests := []Tests{
+		{
+			name:   "streams-join-data-query",
+			server: httptest.NewServer(mux),
+			response: data.NewFrame("response",
+				data.NewField("Timestamp", nil, []time.Time{time.Date(2022, 6, 4, 0, 0, 0, 0, time.UTC), time.Date(2022, 6, 5, 0, 0, 0, 0, time.UTC)}),
+				data.NewField("StreamName1.Value", nil, []*float64{&values[0], nil}),
+				data.NewField("StreamName2.Value", nil, []*float64{&values[1], &values[2]}),
+			),
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer test.server.Close()
+
+			client := NewDataHubClient(test.server.URL, apiVersion, tenantId, "", "")
+			resp, err := StreamsJoinDataQuery(context.Background(), &client, namespaceId, "token", []string{"StreamId1", "StreamId2"}, "", "", 2)
+
+			if !reflect.DeepEqual(resp, test.response) {
+				t.Errorf("FAILED: expected %v, got %v\n", test.response, resp)
+			}
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Expected error FAILED: expected %v, got %v\n", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestStreamsJoinDataQueryPaged(t *testing.T) {
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	mux := newStreamsTestMux(basePath)
+
+	mux.HandleFunc(basePath+"/streams/StreamId2", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{ "TypeId": "StreamType1", "Id": "StreamId2", "Name": "StreamName2" }`))
+	})
+
+	// one row per page, continuing without end
+	mux.HandleFunc(basePath+"/Bulk/Streams/Data/Joins", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["continuationToken"]; !ok || r.URL.Query().Get("count") != "1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		page := 0
+		if token := r.URL.Query().Get("continuationToken"); token != "" {
+			page, _ = strconv.Atoi(token)
+		}
+		timestamp := time.Date(2022, 6, 4, page, 0, 0, 0, time.UTC).Format(time.RFC3339)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"Results": [[{ "Timestamp": "` + timestamp + `", "Value": 1 }, null]],
+			"ContinuationToken": "` + strconv.Itoa(page+1) + `"
+		}`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewDataHubClient(server.URL, apiVersion, tenantId, "", "")
+	client.SetPaging(1, 2)
+	resp, err := StreamsJoinDataQuery(context.Background(), &client, namespaceId, "token", []string{"StreamId1", "StreamId2"}, "", "", 2)
+
+	if err != nil {
+		t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+	if resp.Rows() != 2 {
+		t.Errorf("FAILED: expected %v rows, got %v\n", 2, resp.Rows())
+	}
+	if resp.Meta == nil || len(resp.Meta.Notices) != 1 || !strings.HasPrefix(resp.Meta.Notices[0].Text, "Data truncated to the first 2 rows") {
+		t.Errorf("FAILED: expected a truncation notice, got %v\n", resp.Meta)
+	}
+}
+
+func TestQueryStreamsSelection(t *testing.T) {
+	type selectionTests struct {
+		name          string
+		json          string
+		expectedRows  int
+		expectedError bool
+	}
+
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	mux := newStreamsTestMux(basePath)
+
+	mux.HandleFunc(basePath+"/streams/StreamId1/Data", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[{ "Timestamp": "2022-06-04T00:00:00Z", "Value": 1 }]`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ds := newEdsTestDataSource(t, server)
+	defer ds.Dispose()
+
+	tests := []selectionTests{
+		{name: "single-id", json: `{ "collection": "streams", "ids": ["StreamId1"] }`, expectedRows: 1},
+		{name: "join-interpolated", json: `{ "collection": "streams", "ids": ["StreamId1", "StreamId2"], "mode": "interpolated" }`, expectedError: true},
+		{name: "join-stream-view", json: `{ "collection": "streams", "ids": ["StreamId1", "StreamId2"], "streamViewId": "View1" }`, expectedError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := ds.query(context.Background(), backend.PluginContext{}, backend.DataQuery{JSON: []byte(test.json)}, "")
+
+			if (err != nil) != test.expectedError {
+				t.Fatalf("Expected error FAILED: expected %v, got %v\n", test.expectedError, err)
+			}
+			if test.expectedError {
+				if !backend.IsDownstreamError(err) {
+					t.Errorf("FAILED: expected a downstream error, got %v\n", err)
+				}
+				return
+			}
+			if len(resp.Frames) != 1 || resp.Frames[0].Rows() != test.expectedRows {
+				t.Errorf("FAILED: expected %v rows, got %v\n", test.expectedRows, resp.Frames)
+			}
+		})
+	}
+}
+
+func TestStreamsJoinDataQueryEnum(t *testing.T) {
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+	mux := http.NewServeMux()
+
+	for _, id := range []string{"StreamId1", "StreamId2"} {
+		stream := `{ "TypeId": "EnumType1", "Id": "` + id + `", "Name": "` + id + `" }`
+		mux.HandleFunc(basePath+"/streams/"+id, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(stream))
+		})
+	}
+
+	mux.HandleFunc(basePath+"/types/EnumType1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`
+		{
+			"Id": "EnumType1",
+			"SdsTypeCode": 1,
+			"Properties": [
+				{ "Id": "Timestamp", "IsKey": true, "SdsType": { "SdsTypeCode": 16 } },
+				{
+					"Id": "State",
+					"SdsType": {
+						"SdsTypeCode": 609,
+						"Properties": [
+							{ "Id": "Stopped", "Value": 0 },
+							{ "Id": "Running", "Value": 1 }
+						]
+					}
+				}
+			]
+		}`))
+	})
+
+	mux.HandleFunc(basePath+"/Bulk/Streams/Data/Joins", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[
+			[
+				{ "Timestamp": "2022-06-04T00:00:00Z", "State": "Running" },
+				{ "Timestamp": "2022-06-04T00:00:00Z", "State": "Stopped" }
+			]
+		]`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	states := []int32{1, 0}
+	expected := data.NewFrame("response",
+		data.NewField("Timestamp", nil, []time.Time{time.Date(2022, 6, 4, 0, 0, 0, 0, time.UTC)}),
+		data.NewField("StreamId1.State", nil, []*int32{&states[0]}),
+		data.NewField("StreamId2.State", nil, []*int32{&states[1]}),
+	)
+
+	client := NewDataHubClient(server.URL, apiVersion, tenantId, "", "")
+	resp, err := StreamsJoinDataQuery(context.Background(), &client, namespaceId, "token", []string{"StreamId1", "StreamId2"}, "", "", 2)
+
+	if err != nil {
+		t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+	if !reflect.DeepEqual(resp, expected) {
+		t.Errorf("FAILED: expected %v, got %v\n", expected, resp)
+	}
+}
@@ -0,0 +1,107 @@
+package datahub
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// A size-bounded cache whose entries expire after the TTL. Once the cache is full the least recently
+// used entries are evicted. A nil cache caches nothing.
+type lruCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type lruCacheEntry struct {
+	key        string
+	value      interface{}
+	expiration time.Time
+}
+
+func newLruCache(ttl time.Duration, size int) *lruCache {
+	return &lruCache{
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *lruCache) get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*lruCacheEntry)
+	if time.Now().After(entry.expiration) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// Stores the value, replacing any previous value of the key and restarting its TTL.
+func (c *lruCache) set(key string, value interface{}) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiration := time.Now().Add(c.ttl)
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*lruCacheEntry)
+		entry.value = value
+		entry.expiration = expiration
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruCacheEntry{key: key, value: value, expiration: expiration})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruCacheEntry).key)
+	}
+}
+
+func (c *lruCache) remove(key string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+func (c *lruCache) clear() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
@@ -0,0 +1,67 @@
+package datahub
+
+import (
+	"context"
+	"time"
+)
+
+// Default lifetime and number of entries of the stream and type metadata cache.
+const (
+	defaultMetadataCacheTtl  = 5 * time.Minute
+	defaultMetadataCacheSize = 1000
+)
+
+// Caches responses of stream, type, stream view map and resolved stream requests, which rarely change
+// but are needed by every data query. Entries expire after the TTL and the least recently used entries
+// are evicted once the cache is full. A nil cache caches nothing.
+type metadataCache struct {
+	entries *lruCache
+}
+
+func newMetadataCache(ttl time.Duration, size int) *metadataCache {
+	return &metadataCache{entries: newLruCache(ttl, size)}
+}
+
+func (c *metadataCache) get(key string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	body, ok := c.entries.get(key)
+	if !ok {
+		return nil, false
+	}
+	return body.([]byte), true
+}
+
+func (c *metadataCache) set(key string, body []byte) {
+	if c == nil {
+		return
+	}
+	c.entries.set(key, body)
+}
+
+func (c *metadataCache) clear() {
+	if c == nil {
+		return
+	}
+	c.entries.clear()
+}
+
+// Requests stream or type metadata through the metadata cache. Entries are keyed by the request URL,
+// which names the tenant, namespace and id, and by the community the request is made for. The token is
+// not part of the key: the data requests that follow are still made with the caller's own token.
+func sdsMetadataRequest(ctx context.Context, d *DataHubClient, token string, path string, headers map[string]string) ([]byte, error) {
+	key := path + "\n" + headers[communityIdHeader]
+	if body, ok := d.metadata.get(key); ok {
+		return body, nil
+	}
+
+	body, err := SdsRequest(ctx, d, token, path, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	d.metadata.set(key, body)
+	return body, nil
+}
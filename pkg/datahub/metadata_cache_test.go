@@ -0,0 +1,104 @@
+package datahub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMetadataCache(t *testing.T) {
+	cache := newMetadataCache(time.Minute, 2)
+
+	cache.set("stream1", []byte("1"))
+	cache.set("stream2", []byte("2"))
+	if _, ok := cache.get("stream1"); !ok {
+		t.Fatalf("FAILED: expected stream1 to be cached\n")
+	}
+
+	// stream2 is now the least recently used entry
+	cache.set("stream3", []byte("3"))
+	if _, ok := cache.get("stream2"); ok {
+		t.Errorf("FAILED: expected stream2 to be evicted\n")
+	}
+	if body, ok := cache.get("stream3"); !ok || string(body) != "3" {
+		t.Errorf("FAILED: expected %v, got %v\n", "3", string(body))
+	}
+
+	cache.clear()
+	if _, ok := cache.get("stream1"); ok {
+		t.Errorf("FAILED: expected the cache to be empty after clear\n")
+	}
+
+	expiring := newMetadataCache(time.Millisecond, 2)
+	expiring.set("stream1", []byte("1"))
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := expiring.get("stream1"); ok {
+		t.Errorf("FAILED: expected stream1 to expire\n")
+	}
+
+	var disabled *metadataCache
+	disabled.set("stream1", []byte("1"))
+	if _, ok := disabled.get("stream1"); ok {
+		t.Errorf("FAILED: expected a nil cache to cache nothing\n")
+	}
+}
+
+func TestStreamsDataQueryCachesMetadata(t *testing.T) {
+	type cacheTests struct {
+		name             string
+		ttl              time.Duration
+		expectedRequests int
+	}
+
+	basePath := "/api/" + apiVersion + "/tenants/" + tenantId + "/namespaces/" + namespaceId
+
+	tests := []cacheTests{
+		{name: "cached", ttl: 0, expectedRequests: 1},
+		{name: "disabled", ttl: -1, expectedRequests: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var mu sync.Mutex
+			requests := map[string]int{}
+			mux := newStreamsTestMux(basePath)
+			mux.HandleFunc(basePath+"/streams/StreamId1/Data/Interpolated", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`[]`))
+			})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requests[r.URL.Path]++
+				mu.Unlock()
+				mux.ServeHTTP(w, r)
+			}))
+			defer server.Close()
+
+			client := NewDataHubClient(server.URL, apiVersion, tenantId, "", "")
+			client.SetMetadataCache(test.ttl, 0)
+			for i := 0; i < 3; i++ {
+				_, err := StreamsInterpolatedDataQuery(context.Background(), &client, namespaceId, "token", "StreamId1", "", "", 3)
+				if err != nil {
+					t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+				}
+			}
+
+			for _, path := range []string{basePath + "/streams/StreamId1", basePath + "/types/StreamType1"} {
+				if requests[path] != test.expectedRequests {
+					t.Errorf("FAILED: expected %v requests to %v, got %v\n", test.expectedRequests, path, requests[path])
+				}
+			}
+			if requests[basePath+"/streams/StreamId1/Data/Interpolated"] != 3 {
+				t.Errorf("FAILED: expected %v data requests, got %v\n", 3, requests[basePath+"/streams/StreamId1/Data/Interpolated"])
+			}
+
+			client.Close()
+			if _, ok := client.metadata.get(server.URL + basePath + "/streams/StreamId1\n"); ok {
+				t.Errorf("FAILED: expected the cache to be cleared on close\n")
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package namespace
+
+type Namespace struct {
+	Id          string `json:"Id"`
+	Region      string `json:"Region"`
+	Self        string `json:"Self"`
+	Description string `json:"Description"`
+	State       string `json:"State"`
+}
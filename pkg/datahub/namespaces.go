@@ -0,0 +1,31 @@
+package datahub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/osisoft/sample-adh-grafana_backend_plugin-datasource/pkg/datahub/namespace"
+)
+
+// Lists the namespaces of the configured tenant.
+func NamespacesQuery(ctx context.Context, d *DataHubClient, token string) ([]namespace.Namespace, error) {
+	path := d.resource + "/api/" + d.apiVersion + "/tenants/" + url.QueryEscape(d.tenantId) + "/namespaces"
+
+	body, err := SdsRequest(ctx, d, token, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var namespaces []namespace.Namespace
+
+	err = json.Unmarshal(body, &namespaces)
+	if err != nil {
+		logger.Warn("Error parsing json", err.Error())
+		logger.Warn(fmt.Sprint(string(body)))
+		return nil, err
+	}
+
+	return namespaces, nil
+}
@@ -0,0 +1,44 @@
+package datahub
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// Builds the response of a failed query, with a status and error source that tell Grafana whether
+// Data Hub, the query or the plugin is to blame.
+func errorDataResponse(err error) backend.DataResponse {
+	status, source := getErrorStatusAndSource(err)
+	return backend.DataResponse{
+		Error:       err,
+		Status:      status,
+		ErrorSource: source,
+	}
+}
+
+func getErrorStatusAndSource(err error) (backend.Status, backend.ErrorSource) {
+	var sdsErr *SdsError
+	switch {
+	case errors.Is(err, ErrThrottled):
+		return backend.StatusTooManyRequests, backend.ErrorSourceDownstream
+	case errors.As(err, &sdsErr):
+		return backend.Status(sdsErr.StatusCode), backend.ErrorSourceFromHTTPStatus(sdsErr.StatusCode)
+	case errors.Is(err, context.DeadlineExceeded):
+		return backend.StatusTimeout, backend.ErrorSourceDownstream
+	case errors.Is(err, ErrMissingAuthorization):
+		return backend.StatusUnauthorized, backend.ErrorSourceDownstream
+	case errors.Is(err, ErrTokenUnavailable):
+		return backend.StatusBadGateway, backend.ErrorSourceDownstream
+	case errors.Is(err, ErrInvalidStreamSelf):
+		return backend.StatusBadRequest, backend.ErrorSourceDownstream
+	case backend.IsDownstreamError(err):
+		// invalid queries are marked as downstream errors where they are detected
+		return backend.StatusBadRequest, backend.ErrorSourceDownstream
+	case backend.IsDownstreamHTTPError(err):
+		return backend.StatusBadGateway, backend.ErrorSourceDownstream
+	default:
+		return backend.StatusInternal, backend.ErrorSourcePlugin
+	}
+}
@@ -0,0 +1,136 @@
+package datahub
+
+import (
+	"context"
+	"fmt"
+	"net/textproto"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+const redacted = "[REDACTED]"
+
+// Headers that carry credentials, compared in canonical form.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Id-Token":          true,
+	"X-Grafana-Id":        true,
+}
+
+// Grafana prefixes forwarded HTTP headers in request header maps.
+const forwardedHeaderPrefix = "http_"
+
+var (
+	bearerTokenPattern = regexp.MustCompile(`(?i)\b(bearer)\s+[A-Za-z0-9\-._~+/]+=*`)
+	jsonSecretPattern  = regexp.MustCompile(`(?i)("(?:access_token|refresh_token|id_token|client_secret|clientSecret|password)"\s*:\s*)"[^"]*"`)
+	formSecretPattern  = regexp.MustCompile(`(?i)\b(access_token|refresh_token|id_token|client_secret|password)=[^&\s"]*`)
+	headerLinePattern  = regexp.MustCompile(`(?im)^((?:authorization|proxy-authorization|cookie|set-cookie|x-id-token|x-grafana-id)\s*:\s*).*$`)
+)
+
+// Logger used throughout the package, which strips credentials before anything reaches the plugin logs.
+var logger log.Logger = &redactingLogger{logger: log.DefaultLogger}
+
+type redactingLogger struct {
+	logger log.Logger
+}
+
+func (l *redactingLogger) Debug(msg string, args ...interface{}) {
+	l.logger.Debug(redactText(msg), redactArgs(args)...)
+}
+
+func (l *redactingLogger) Info(msg string, args ...interface{}) {
+	l.logger.Info(redactText(msg), redactArgs(args)...)
+}
+
+func (l *redactingLogger) Warn(msg string, args ...interface{}) {
+	l.logger.Warn(redactText(msg), redactArgs(args)...)
+}
+
+func (l *redactingLogger) Error(msg string, args ...interface{}) {
+	l.logger.Error(redactText(msg), redactArgs(args)...)
+}
+
+func (l *redactingLogger) With(args ...interface{}) log.Logger {
+	return &redactingLogger{logger: l.logger.With(redactArgs(args)...)}
+}
+
+func (l *redactingLogger) Level() log.Level {
+	return l.logger.Level()
+}
+
+func (l *redactingLogger) FromContext(ctx context.Context) log.Logger {
+	return &redactingLogger{logger: l.logger.FromContext(ctx)}
+}
+
+// Redacts log arguments. Values that are neither text nor headers are logged as text, so that
+// credentials nested in structs are redacted as well.
+func redactArgs(args []interface{}) []interface{} {
+	redactedArgs := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch value := arg.(type) {
+		case nil, bool, int, int32, int64, float32, float64:
+			redactedArgs[i] = value
+		case string:
+			redactedArgs[i] = redactText(value)
+		case map[string]string:
+			redactedArgs[i] = redactHeaders(value)
+		default:
+			redactedArgs[i] = redactText(fmt.Sprint(value))
+		}
+	}
+	return redactedArgs
+}
+
+// Removes bearer tokens, secrets and credential headers from free text such as response bodies.
+func redactText(text string) string {
+	text = bearerTokenPattern.ReplaceAllString(text, "$1 "+redacted)
+	text = jsonSecretPattern.ReplaceAllString(text, `$1"`+redacted+`"`)
+	text = formSecretPattern.ReplaceAllString(text, "$1="+redacted)
+	text = headerLinePattern.ReplaceAllString(text, "$1"+redacted)
+	return text
+}
+
+// Copies the headers with the values of credential headers replaced.
+func redactHeaders(headers map[string]string) map[string]string {
+	redactedHeaders := make(map[string]string, len(headers))
+	for k, v := range headers {
+		if isSensitiveHeader(k) {
+			redactedHeaders[k] = redacted
+		} else {
+			redactedHeaders[k] = redactText(v)
+		}
+	}
+	return redactedHeaders
+}
+
+func isSensitiveHeader(key string) bool {
+	if len(key) > len(forwardedHeaderPrefix) && strings.EqualFold(key[:len(forwardedHeaderPrefix)], forwardedHeaderPrefix) {
+		key = key[len(forwardedHeaderPrefix):]
+	}
+	return sensitiveHeaders[textproto.CanonicalMIMEHeaderKey(key)]
+}
+
+// Summarizes a query request for logging. The request itself is never logged, since its plugin
+// context holds the decrypted secure settings and its headers may hold the user's token.
+func describeQueryDataRequest(req *backend.QueryDataRequest) map[string]interface{} {
+	refIds := make([]string, len(req.Queries))
+	for i, q := range req.Queries {
+		refIds[i] = q.RefID
+	}
+
+	description := map[string]interface{}{
+		"orgId":   req.PluginContext.OrgID,
+		"refIds":  refIds,
+		"headers": redactHeaders(req.Headers),
+	}
+	if req.PluginContext.DataSourceInstanceSettings != nil {
+		description["datasource"] = req.PluginContext.DataSourceInstanceSettings.UID
+	}
+	return description
+}
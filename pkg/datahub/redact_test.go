@@ -0,0 +1,111 @@
+package datahub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+type capturingLogger struct {
+	messages []string
+}
+
+func (l *capturingLogger) capture(msg string, args ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprint(append([]interface{}{msg}, args...)...))
+}
+
+func (l *capturingLogger) Debug(msg string, args ...interface{}) { l.capture(msg, args...) }
+func (l *capturingLogger) Info(msg string, args ...interface{})  { l.capture(msg, args...) }
+func (l *capturingLogger) Warn(msg string, args ...interface{})  { l.capture(msg, args...) }
+func (l *capturingLogger) Error(msg string, args ...interface{}) { l.capture(msg, args...) }
+func (l *capturingLogger) With(args ...interface{}) log.Logger   { return l }
+func (l *capturingLogger) Level() log.Level                      { return log.Debug }
+func (l *capturingLogger) FromContext(ctx context.Context) log.Logger {
+	return l
+}
+
+func TestRedactText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{name: "bearer", text: "Authorization failed for Bearer eyJhbGciOi.eyJzdWIiOi.c2lnbmF0dXJl", expected: "Authorization failed for Bearer [REDACTED]"},
+		{name: "json-token", text: `{"access_token": "eyJhbGciOi", "expires_in": 3600}`, expected: `{"access_token": "[REDACTED]", "expires_in": 3600}`},
+		{name: "json-secret", text: `{"clientId":"client1","clientSecret":"s3cr3t"}`, expected: `{"clientId":"client1","clientSecret":"[REDACTED]"}`},
+		{name: "form", text: "client_id=client1&client_secret=s3cr3t&grant_type=client_credentials", expected: "client_id=client1&client_secret=[REDACTED]&grant_type=client_credentials"},
+		{name: "header-line", text: "Status: 400\nCookie: grafana_session=abc123\nBody: bad", expected: "Status: 400\nCookie: [REDACTED]\nBody: bad"},
+		{name: "plain", text: "Status: 404 Not Found\nBody: stream not found", expected: "Status: 404 Not Found\nBody: stream not found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if text := redactText(test.text); text != test.expected {
+				t.Errorf("FAILED: expected %v, got %v\n", test.expected, text)
+			}
+		})
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	headers := map[string]string{
+		"Authorization":  "Bearer token1",
+		"http_Cookie":    "grafana_session=abc123",
+		"X-ID-Token":     "eyJhbGciOi",
+		"Content-Type":   "application/json",
+		"X-Grafana-Org":  "1",
+		"x-grafana-id":   "eyJhbGciOi",
+		"Community-Id":   "community1",
+		"http_Forwarded": "for=192.0.2.60",
+	}
+	expected := map[string]string{
+		"Authorization":  redacted,
+		"http_Cookie":    redacted,
+		"X-ID-Token":     redacted,
+		"Content-Type":   "application/json",
+		"X-Grafana-Org":  "1",
+		"x-grafana-id":   redacted,
+		"Community-Id":   "community1",
+		"http_Forwarded": "for=192.0.2.60",
+	}
+
+	if redactedHeaders := redactHeaders(headers); !reflect.DeepEqual(redactedHeaders, expected) {
+		t.Errorf("FAILED: expected %v, got %v\n", expected, redactedHeaders)
+	}
+	if headers["Authorization"] != "Bearer token1" {
+		t.Errorf("FAILED: expected the original headers to be unchanged, got %v\n", headers)
+	}
+}
+
+func TestRedactingLogger(t *testing.T) {
+	capture := &capturingLogger{}
+	logger := &redactingLogger{logger: capture}
+
+	logger.Warn("Error making request", errors.New("Status: 401 Unauthorized\nBody: invalid token Bearer token1"))
+	logger.Info("Request", "headers", map[string]string{"Authorization": "Bearer token1"})
+	logger.Debug("QueryData called", "request", describeQueryDataRequest(&backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+				UID:                     "datasource1",
+				DecryptedSecureJSONData: map[string]string{"clientSecret": "s3cr3t"},
+			},
+		},
+		Headers: map[string]string{"Authorization": "Bearer token1"},
+		Queries: []backend.DataQuery{{RefID: "A"}},
+	}))
+
+	for _, message := range capture.messages {
+		if strings.Contains(message, "token1") || strings.Contains(message, "s3cr3t") {
+			t.Errorf("FAILED: expected credentials to be redacted, got %v\n", message)
+		}
+	}
+	if !strings.Contains(capture.messages[2], "datasource1") {
+		t.Errorf("FAILED: expected the request summary to name the datasource, got %v\n", capture.messages[2])
+	}
+}
@@ -0,0 +1,106 @@
+package datahub
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Coalesces identical SDS requests: while a request is in flight, callers making the same request wait
+// for its result instead of sending their own. The shared request is not tied to any caller's context;
+// it is cancelled once every caller waiting on it has given up. A nil group coalesces nothing.
+type requestGroup struct {
+	mu    sync.Mutex
+	calls map[string]*sharedRequest
+}
+
+// An in-flight request, shared by every caller waiting on it.
+type sharedRequest struct {
+	done    chan struct{}
+	waiters int
+	cancel  context.CancelFunc
+	body    []byte
+	header  http.Header
+	err     error
+}
+
+func newRequestGroup() *requestGroup {
+	return &requestGroup{calls: make(map[string]*sharedRequest)}
+}
+
+// Returns the result of the in-flight request with the same key, or runs fn as that request.
+func (g *requestGroup) do(ctx context.Context, key string, fn func(ctx context.Context) ([]byte, http.Header, error)) ([]byte, http.Header, error) {
+	if g == nil {
+		return fn(ctx)
+	}
+
+	g.mu.Lock()
+	call, ok := g.calls[key]
+	if !ok {
+		sharedCtx, cancel := context.WithCancel(context.Background())
+		call = &sharedRequest{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = call
+		go g.run(sharedCtx, key, call, fn)
+	}
+	call.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.body, call.header, call.err
+	case <-ctx.Done():
+		g.leave(key, call)
+		return nil, nil, ctx.Err()
+	}
+}
+
+func (g *requestGroup) run(ctx context.Context, key string, call *sharedRequest, fn func(ctx context.Context) ([]byte, http.Header, error)) {
+	defer call.cancel()
+	call.body, call.header, call.err = fn(ctx)
+
+	g.mu.Lock()
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+	close(call.done)
+}
+
+// Stops waiting on a request, cancelling it when no caller is left. A cancelled request is forgotten
+// right away, so that later callers start a new request instead of sharing the cancellation.
+func (g *requestGroup) leave(key string, call *sharedRequest) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	call.waiters--
+	if call.waiters == 0 {
+		if g.calls[key] == call {
+			delete(g.calls, key)
+		}
+		call.cancel()
+	}
+}
+
+// Identifies a request by everything that affects its response: the URL, the credentials and the
+// additional headers, such as the community the request is made for.
+func getRequestKey(token string, path string, headers map[string]string) string {
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var key strings.Builder
+	key.WriteString(path)
+	key.WriteString("\n")
+	key.WriteString(token)
+	for _, k := range keys {
+		key.WriteString("\n")
+		key.WriteString(k)
+		key.WriteString(": ")
+		key.WriteString(headers[k])
+	}
+	return key.String()
+}
@@ -0,0 +1,141 @@
+package datahub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Waits until the given number of callers wait on the only in-flight request of the group.
+func waitForWaiters(t *testing.T, g *requestGroup, waiters int) {
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(time.Millisecond) {
+		g.mu.Lock()
+		total := 0
+		for _, call := range g.calls {
+			total += call.waiters
+		}
+		g.mu.Unlock()
+		if total == waiters {
+			return
+		}
+	}
+	t.Fatalf("FAILED: expected %v waiting callers\n", waiters)
+}
+
+func TestSdsRequestCoalescing(t *testing.T) {
+	type coalescingTests struct {
+		name             string
+		tokens           []string
+		expectedRequests int32
+	}
+
+	tests := []coalescingTests{
+		{name: "identical-requests", tokens: []string{"token1", "token1", "token1", "token1"}, expectedRequests: 1},
+		{name: "different-credentials", tokens: []string{"token1", "token2", "token1", "token2"}, expectedRequests: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests int32
+			release := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				<-release
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(r.Header.Get("Authorization")))
+			}))
+			defer server.Close()
+
+			client := NewDataHubClient(server.URL, apiVersion, tenantId, "", "")
+			bodies := make([]string, len(test.tokens))
+			errs := make([]error, len(test.tokens))
+			var wg sync.WaitGroup
+			for i, token := range test.tokens {
+				wg.Add(1)
+				go func(i int, token string) {
+					defer wg.Done()
+					body, err := SdsRequest(context.Background(), &client, token, server.URL+"/streams", nil)
+					bodies[i], errs[i] = string(body), err
+				}(i, token)
+			}
+
+			waitForWaiters(t, client.requests, len(test.tokens))
+			close(release)
+			wg.Wait()
+
+			if requests := atomic.LoadInt32(&requests); requests != test.expectedRequests {
+				t.Errorf("FAILED: expected %v requests, got %v\n", test.expectedRequests, requests)
+			}
+			for i, token := range test.tokens {
+				if errs[i] != nil || bodies[i] != token {
+					t.Errorf("FAILED: expected %v, got %v %v\n", token, bodies[i], errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSdsRequestCoalescingCancellation(t *testing.T) {
+	release := make(chan struct{})
+	cancelled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`[]`))
+		case <-r.Context().Done():
+			close(cancelled)
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewDataHubClient(server.URL, apiVersion, tenantId, "", "")
+	path := server.URL + "/streams"
+
+	// a caller giving up does not fail the callers still waiting
+	ctx, cancel := context.WithCancel(context.Background())
+	leaving := make(chan error)
+	go func() {
+		_, err := SdsRequest(ctx, &client, "token", path, nil)
+		leaving <- err
+	}()
+	waitForWaiters(t, client.requests, 1)
+
+	staying := make(chan error)
+	go func() {
+		_, err := SdsRequest(context.Background(), &client, "token", path, nil)
+		staying <- err
+	}()
+	waitForWaiters(t, client.requests, 2)
+
+	cancel()
+	if err := <-leaving; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected error FAILED: expected %v, got %v\n", context.Canceled, err)
+	}
+	release <- struct{}{}
+	if err := <-staying; err != nil {
+		t.Errorf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+
+	// the request is cancelled once every caller has given up
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		_, err := SdsRequest(ctx, &client, "token", path, nil)
+		leaving <- err
+	}()
+	waitForWaiters(t, client.requests, 1)
+	cancel()
+	<-leaving
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Errorf("FAILED: expected the abandoned request to be cancelled\n")
+	}
+}
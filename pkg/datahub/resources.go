@@ -0,0 +1,73 @@
+package datahub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/osisoft/sample-adh-grafana_backend_plugin-datasource/pkg/datahub/namespace"
+)
+
+type ResourceErrorBody struct {
+	Message string `json:"message"`
+}
+
+// Handles resource calls from the query editor.
+func (d *DataHubDataSource) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req.Method != http.MethodGet {
+		return sendResourceError(sender, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+
+	switch req.Path {
+	case "namespaces":
+		return d.namespacesResource(ctx, req, sender)
+	default:
+		return sendResourceError(sender, http.StatusNotFound, "Resource not found")
+	}
+}
+
+// Lists the configured namespaces of the tenant so the query editor can offer them as overrides.
+func (d *DataHubDataSource) namespacesResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	token, err := d.getToken(ctx, req.GetHTTPHeader("Authorization"))
+	if err != nil {
+		return sendResourceError(sender, http.StatusUnauthorized, "Unable to retrieve token")
+	}
+
+	namespaces, err := NamespacesQuery(ctx, d.dataHubClient, token)
+	if err != nil {
+		logger.Warn("Error listing namespaces", "err", err)
+		if errors.Is(err, ErrThrottled) {
+			return sendResourceError(sender, http.StatusTooManyRequests, "Throttled by Data Hub, try again later")
+		}
+		return sendResourceError(sender, http.StatusBadGateway, "Unable to list namespaces")
+	}
+
+	// queries may only read the configured namespaces
+	configured := []namespace.Namespace{}
+	for _, n := range namespaces {
+		if containsFold(d.namespaceIds, n.Id) {
+			configured = append(configured, n)
+		}
+	}
+
+	return sendResourceJson(sender, http.StatusOK, configured)
+}
+
+func sendResourceJson(sender backend.CallResourceResponseSender, status int, value interface{}) error {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  status,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
+
+func sendResourceError(sender backend.CallResourceResponseSender, status int, message string) error {
+	return sendResourceJson(sender, status, ResourceErrorBody{Message: message})
+}
@@ -0,0 +1,154 @@
+package datahub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/osisoft/sample-adh-grafana_backend_plugin-datasource/pkg/datahub/namespace"
+)
+
+func newEdsTestDataSource(t *testing.T, server *httptest.Server) *DataHubDataSource {
+	return newEdsTestDataSourceWithNamespaces(t, server)
+}
+
+// Creates an Edge Data Store datasource that queries may also read the given namespaces from.
+func newEdsTestDataSourceWithNamespaces(t *testing.T, server *httptest.Server, namespaceIds ...string) *DataHubDataSource {
+	serverUrl, _ := url.Parse(server.URL)
+	namespaceIdsJson, _ := json.Marshal(namespaceIds)
+	settings := backend.DataSourceInstanceSettings{
+		JSONData: []byte(`{ "type": "EDS", "edsHost": "` + serverUrl.Hostname() + `", "edsPort": "` + serverUrl.Port() + `", "namespaceIds": ` + string(namespaceIdsJson) + ` }`),
+	}
+
+	instance, err := NewDataHubDataSource(context.Background(), settings)
+	if err != nil {
+		t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+	return instance.(*DataHubDataSource)
+}
+
+func TestNamespacesResource(t *testing.T) {
+	type resourceTests struct {
+		name           string
+		path           string
+		method         string
+		expectedStatus int
+		expected       []namespace.Namespace
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/tenants/default/namespaces" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[{ "Id": "production", "Region": "WestUS" }, { "Id": "staging", "Region": "WestUS" }]`))
+	}))
+	defer server.Close()
+
+	ds := newEdsTestDataSourceWithNamespaces(t, server, "staging")
+	defer ds.Dispose()
+
+	tests := []resourceTests{
+		{
+			name:           "namespaces",
+			path:           "namespaces",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expected: []namespace.Namespace{
+				{Id: "staging", Region: "WestUS"},
+			},
+		},
+		{
+			name:           "unknown-resource",
+			path:           "unknown",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "wrong-method",
+			path:           "namespaces",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var response *backend.CallResourceResponse
+			err := ds.CallResource(context.Background(), &backend.CallResourceRequest{Path: test.path, Method: test.method}, backend.CallResourceResponseSenderFunc(func(resp *backend.CallResourceResponse) error {
+				response = resp
+				return nil
+			}))
+			if err != nil {
+				t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+			}
+			if response.Status != test.expectedStatus {
+				t.Fatalf("FAILED: expected status %v, got %v\n", test.expectedStatus, response.Status)
+			}
+			if test.expected == nil {
+				return
+			}
+
+			var namespaces []namespace.Namespace
+			json.Unmarshal(response.Body, &namespaces)
+			if !reflect.DeepEqual(namespaces, test.expected) {
+				t.Errorf("FAILED: expected %v, got %v\n", test.expected, namespaces)
+			}
+		})
+	}
+}
+
+func TestQueryNamespaceOverride(t *testing.T) {
+	var mu sync.Mutex
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	ds := newEdsTestDataSourceWithNamespaces(t, server, "staging")
+	defer ds.Dispose()
+
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{ "collection": "streams", "queryText": "" }`)},
+			{RefID: "B", JSON: []byte(`{ "collection": "streams", "queryText": "", "namespaceId": "Staging" }`)},
+			{RefID: "C", JSON: []byte(`{ "collection": "streams", "queryText": "", "namespaceId": "production" }`)},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+
+	// namespaces that are not configured are rejected without a request
+	if resp.Responses["B"].Error != nil {
+		t.Errorf("Expected error FAILED: expected %v, got %v\n", nil, resp.Responses["B"].Error)
+	}
+	unconfigured := resp.Responses["C"]
+	if unconfigured.Error == nil || unconfigured.ErrorSource != backend.ErrorSourceDownstream {
+		t.Errorf("FAILED: expected a downstream error, got %v from %v\n", unconfigured.Error, unconfigured.ErrorSource)
+	}
+
+	expected := []string{
+		"/api/v1/tenants/default/namespaces/default/streams",
+		"/api/v1/tenants/default/namespaces/staging/streams",
+	}
+	sort.Strings(requested)
+	if !reflect.DeepEqual(requested, expected) {
+		t.Errorf("FAILED: expected %v, got %v\n", expected, requested)
+	}
+}
@@ -0,0 +1,81 @@
+package datahub
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Returned, wrapped, when Data Hub keeps throttling a request after every retry.
+var ErrThrottled = errors.New("throttled by Data Hub")
+
+// Controls how requests failing with a transient status are retried.
+type RetryPolicy struct {
+	// Total number of attempts, including the first one.
+	MaxAttempts int
+	// Delay before the first retry, doubled for every retry after it.
+	InitialBackoff time.Duration
+	// Upper bound of the delay between attempts, including delays requested through Retry-After.
+	MaxBackoff time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+	}
+}
+
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func isThrottledStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+}
+
+// Determines how long to wait before the given retry, preferring the server's Retry-After header.
+// Returns false when the server asks to wait longer than the policy allows.
+func (p RetryPolicy) backoff(retry int, retryAfter string) (time.Duration, bool) {
+	if retryAfter != "" {
+		if delay, ok := parseRetryAfter(retryAfter); ok {
+			return delay, delay <= p.MaxBackoff
+		}
+	}
+
+	// exponential backoff with jitter, between half and all of the exponential delay
+	delay := p.InitialBackoff
+	for i := 1; i < retry && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0, true
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1)), true
+}
+
+// Parses a Retry-After header, given either as a number of seconds or as an HTTP date.
+func parseRetryAfter(retryAfter string) (time.Duration, bool) {
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(retryAfter); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
@@ -0,0 +1,77 @@
+package datahub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSdsRequestRetry(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := NewDataHubClient(server.URL, apiVersion, tenantId, "", "")
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	body, err := SdsRequest(context.Background(), &client, "token", server.URL, nil)
+	if err != nil {
+		t.Errorf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+	if string(body) != "{}" {
+		t.Errorf("FAILED: expected %v, got %v\n", "{}", string(body))
+	}
+	if requests != 3 {
+		t.Errorf("FAILED: expected %v requests, got %v\n", 3, requests)
+	}
+}
+
+func TestSdsRequestThrottled(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewDataHubClient(server.URL, apiVersion, tenantId, "", "")
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	_, err := SdsRequest(context.Background(), &client, "token", server.URL, nil)
+	if !errors.Is(err, ErrThrottled) {
+		t.Errorf("Expected error FAILED: expected %v, got %v\n", ErrThrottled, err)
+	}
+	if requests != 2 {
+		t.Errorf("FAILED: expected %v requests, got %v\n", 2, requests)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	for retry := 1; retry <= 5; retry++ {
+		delay, ok := policy.backoff(retry, "")
+		if !ok || delay > policy.MaxBackoff {
+			t.Errorf("FAILED: retry %d waits %v, expected at most %v\n", retry, delay, policy.MaxBackoff)
+		}
+	}
+
+	if delay, ok := policy.backoff(1, "1"); !ok || delay != time.Second {
+		t.Errorf("FAILED: expected Retry-After of %v to be honored, got %v\n", time.Second, delay)
+	}
+	if _, ok := policy.backoff(1, "120"); ok {
+		t.Errorf("FAILED: expected Retry-After beyond the maximum backoff to stop retries\n")
+	}
+}
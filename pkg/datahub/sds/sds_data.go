@@ -0,0 +1,21 @@
+package sds
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// Decodes SDS event data, keeping numbers as json.Number so that Int64 and UInt64 values beyond the
+// precision of a float64 reach the conversion to their column type unchanged.
+func UnmarshalData(b []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("invalid character after top-level value")
+	}
+	return nil
+}
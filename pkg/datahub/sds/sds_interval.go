@@ -0,0 +1,7 @@
+package sds
+
+type SdsInterval struct {
+	Start     map[string]interface{}            `json:"Start"`
+	End       map[string]interface{}            `json:"End"`
+	Summaries map[string]map[string]interface{} `json:"Summaries"`
+}
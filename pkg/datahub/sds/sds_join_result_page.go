@@ -0,0 +1,29 @@
+package sds
+
+// A page of joined rows, each holding the event of every joined stream at the row's index.
+type SdsJoinResultPage struct {
+	Results           [][]map[string]interface{} `json:"Results"`
+	ContinuationToken string                     `json:"ContinuationToken"`
+	// False when the response was a plain list of rows rather than a page.
+	Paged bool `json:"-"`
+}
+
+func (sdsJoinResultPage *SdsJoinResultPage) UnmarshalJSON(b []byte) error {
+	var results [][]map[string]interface{}
+	if err := UnmarshalData(b, &results); err == nil {
+		sdsJoinResultPage.Results = results
+		sdsJoinResultPage.ContinuationToken = ""
+		sdsJoinResultPage.Paged = false
+		return nil
+	}
+
+	type resultPage SdsJoinResultPage
+	var page resultPage
+	if err := UnmarshalData(b, &page); err != nil {
+		return err
+	}
+
+	*sdsJoinResultPage = SdsJoinResultPage(page)
+	sdsJoinResultPage.Paged = true
+	return nil
+}
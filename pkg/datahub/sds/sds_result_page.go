@@ -0,0 +1,30 @@
+package sds
+
+type SdsResultPage struct {
+	Results           []map[string]interface{} `json:"Results"`
+	ContinuationToken string                   `json:"ContinuationToken"`
+	// False when the response was a plain list of events rather than a page, which SDS returns when
+	// the request has no continuationToken parameter.
+	Paged bool `json:"-"`
+}
+
+func (sdsResultPage *SdsResultPage) UnmarshalJSON(b []byte) error {
+	// a plain list of events carries no continuation token, so it cannot tell whether more events follow
+	var results []map[string]interface{}
+	if err := UnmarshalData(b, &results); err == nil {
+		sdsResultPage.Results = results
+		sdsResultPage.ContinuationToken = ""
+		sdsResultPage.Paged = false
+		return nil
+	}
+
+	type resultPage SdsResultPage
+	var page resultPage
+	if err := UnmarshalData(b, &page); err != nil {
+		return err
+	}
+
+	*sdsResultPage = SdsResultPage(page)
+	sdsResultPage.Paged = true
+	return nil
+}
@@ -0,0 +1,12 @@
+package sds
+
+type SdsStreamViewMap struct {
+	SourceTypeId string                     `json:"SourceTypeId"`
+	TargetTypeId string                     `json:"TargetTypeId"`
+	Properties   []SdsStreamViewMapProperty `json:"Properties"`
+}
+
+type SdsStreamViewMapProperty struct {
+	SourceId string `json:"SourceId"`
+	TargetId string `json:"TargetId"`
+}
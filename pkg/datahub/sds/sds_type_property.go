@@ -1,7 +1,9 @@
 package sds
 
 type SdsTypeProperty struct {
-	Id      string  `json:"Id"`
-	Name    string  `json:"Name"`
-	SdsType SdsType `json:"SdsType"`
+	Id      string      `json:"Id"`
+	Name    string      `json:"Name"`
+	IsKey   bool        `json:"IsKey"`
+	SdsType SdsType     `json:"SdsType"`
+	Value   interface{} `json:"Value"`
 }
@@ -0,0 +1,13 @@
+package datahub
+
+// An unsuccessful response from Data Hub.
+type SdsError struct {
+	StatusCode int
+	Status     string
+	Body       string
+	RetryAfter string
+}
+
+func (e *SdsError) Error() string {
+	return "Status: " + e.Status + "\nBody: " + e.Body
+}
@@ -0,0 +1,152 @@
+package datahub
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Tokens are considered expired this long before their actual expiration.
+const tokenExpirationWindow = 5 * time.Minute
+
+// Background refreshes happen this long before a token enters the expiration window.
+const tokenRefreshLead = time.Minute
+
+// Limit for a background refresh when no request timeout is configured. Without one a hung identity
+// request would stay the shared token request, and every caller needing a token would join it.
+const tokenRefreshTimeout = 30 * time.Second
+
+// Caches the client credentials token of a DataHubClient. Concurrent callers needing a new token share
+// a single identity request through a request group, and once a token has been issued it is refreshed in
+// the background before it enters the expiration window, so queries rarely wait on the identity server.
+type tokenManager struct {
+	mu         sync.Mutex
+	token      string
+	previous   string
+	expiration time.Time
+	refreshes  *requestGroup
+	timer      *time.Timer
+	closed     bool
+	// limit for background refreshes when the client has no request timeout
+	refreshTimeout time.Duration
+}
+
+// Key of the token request in the manager's request group.
+const tokenRequestKey = "token"
+
+func newTokenManager() *tokenManager {
+	return &tokenManager{refreshes: newRequestGroup(), refreshTimeout: tokenRefreshTimeout}
+}
+
+// Returns a valid bearer token, requesting a new one if the cached token is missing or about to expire.
+func (m *tokenManager) get(ctx context.Context, d *DataHubClient) (string, error) {
+	m.mu.Lock()
+	if time.Until(m.expiration) > tokenExpirationWindow {
+		token := m.token
+		m.mu.Unlock()
+		return "Bearer " + token, nil
+	}
+	m.mu.Unlock()
+
+	return m.refresh(ctx, d)
+}
+
+// Requests a new token, joining the token request of other callers if one is in flight, and caches it.
+func (m *tokenManager) refresh(ctx context.Context, d *DataHubClient) (string, error) {
+	body, _, err := m.refreshes.do(ctx, tokenRequestKey, func(ctx context.Context) ([]byte, http.Header, error) {
+		token, expiresIn, err := requestClientToken(ctx, d)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		m.mu.Lock()
+		if m.token != token {
+			m.previous = m.token
+		}
+		m.token = token
+		m.expiration = time.Now().Add(time.Duration(expiresIn) * time.Second)
+		m.scheduleRefresh(d)
+		m.mu.Unlock()
+
+		return []byte(token), nil, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return "Bearer " + string(body), nil
+}
+
+// Schedules a background refresh ahead of the expiration window. Must be called with the lock held.
+func (m *tokenManager) scheduleRefresh(d *DataHubClient) {
+	if m.closed {
+		return
+	}
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+
+	delay := time.Until(m.expiration) - tokenExpirationWindow - tokenRefreshLead
+	if delay <= 0 {
+		return
+	}
+
+	m.timer = time.AfterFunc(delay, func() {
+		m.refreshInBackground(d)
+	})
+}
+
+// Refreshes the token ahead of its expiration, giving up after the request timeout so that a hung
+// identity request does not block later callers.
+func (m *tokenManager) refreshInBackground(d *DataHubClient) {
+	m.mu.Lock()
+	closed := m.closed
+	timeout := m.refreshTimeout
+	m.mu.Unlock()
+	if closed {
+		return
+	}
+	if d.requestTimeout > 0 {
+		timeout = d.requestTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	_, err := m.refresh(ctx, d)
+	if err != nil {
+		logger.Warn("Error refreshing token in background", err.Error())
+	}
+}
+
+// Reports whether the bearer token was issued by this manager, as opposed to being passed through from
+// the signed in user. Tokens replaced by a recent refresh still count, so requests already holding them
+// can retry with the new token.
+func (m *tokenManager) issued(bearer string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return (m.token != "" && bearer == "Bearer "+m.token) || (m.previous != "" && bearer == "Bearer "+m.previous)
+}
+
+// Discards the cached token if it is the given bearer token, so the next caller requests a new one.
+func (m *tokenManager) invalidate(bearer string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.token != "" && bearer == "Bearer "+m.token {
+		m.expiration = time.Time{}
+	}
+}
+
+// Stops background refreshes.
+func (m *tokenManager) close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+}
@@ -0,0 +1,162 @@
+package datahub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Registers the OpenID configuration and a token endpoint issuing numbered tokens, counting token requests.
+func handleIdentity(mux *http.ServeMux, serverURL *string, tokenRequests *int32) {
+	mux.HandleFunc("/identity/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{ "token_endpoint": "` + *serverURL + `/identity/connect/token" }`))
+	})
+
+	mux.HandleFunc("/identity/connect/token", func(w http.ResponseWriter, r *http.Request) {
+		count := atomic.AddInt32(tokenRequests, 1)
+		// hold the request open so concurrent callers overlap
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{ "access_token": "token` + strconv.Itoa(int(count)) + `", "expires_in": 3600 }`))
+	})
+}
+
+func TestGetClientTokenSingleFlight(t *testing.T) {
+	var tokenRequests int32
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	handleIdentity(mux, &server.URL, &tokenRequests)
+
+	client := NewDataHubClient(server.URL, apiVersion, tenantId, "clientId", "clientSecret")
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := GetClientToken(context.Background(), &client)
+			if err != nil {
+				t.Errorf("Expected error FAILED: expected %v, got %v\n", nil, err)
+			}
+			if token != "Bearer token1" {
+				t.Errorf("FAILED: expected %v, got %v\n", "Bearer token1", token)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// the cached token is reused
+	if _, err := GetClientToken(context.Background(), &client); err != nil {
+		t.Errorf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+
+	if tokenRequests != 1 {
+		t.Errorf("FAILED: expected %v token request, got %v\n", 1, tokenRequests)
+	}
+}
+
+func TestSdsRequestUnauthorizedRetry(t *testing.T) {
+	var tokenRequests int32
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	handleIdentity(mux, &server.URL, &tokenRequests)
+
+	// only the second token is accepted, as if the first had been revoked
+	mux.HandleFunc("/resource", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+	})
+
+	client := NewDataHubClient(server.URL, apiVersion, tenantId, "clientId", "clientSecret")
+	defer client.Close()
+
+	token, err := GetClientToken(context.Background(), &client)
+	if err != nil {
+		t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+
+	body, err := SdsRequest(context.Background(), &client, token, server.URL+"/resource", nil)
+	if err != nil {
+		t.Errorf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+	if string(body) != "{}" {
+		t.Errorf("FAILED: expected %v, got %v\n", "{}", string(body))
+	}
+	if tokenRequests != 2 {
+		t.Errorf("FAILED: expected %v token requests, got %v\n", 2, tokenRequests)
+	}
+
+	// pass-through tokens are not refreshed
+	_, err = SdsRequest(context.Background(), &client, "Bearer user", server.URL+"/resource", nil)
+	var sdsErr *SdsError
+	if !errors.As(err, &sdsErr) || sdsErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected error FAILED: expected %v, got %v\n", http.StatusUnauthorized, err)
+	}
+	if tokenRequests != 2 {
+		t.Errorf("FAILED: expected %v token requests, got %v\n", 2, tokenRequests)
+	}
+}
+
+func TestGetClientTokenAfterHungBackgroundRefresh(t *testing.T) {
+	var tokenRequests int32
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	defer close(release)
+
+	mux.HandleFunc("/identity/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{ "token_endpoint": "` + server.URL + `/identity/connect/token" }`))
+	})
+
+	// the first token request hangs until the test ends
+	mux.HandleFunc("/identity/connect/token", func(w http.ResponseWriter, r *http.Request) {
+		count := atomic.AddInt32(&tokenRequests, 1)
+		if count == 1 {
+			<-release
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{ "access_token": "token` + strconv.Itoa(int(count)) + `", "expires_in": 3600 }`))
+	})
+
+	client := NewDataHubClient(server.URL, apiVersion, tenantId, "clientId", "clientSecret")
+	defer client.Close()
+	client.tokens.refreshTimeout = 100 * time.Millisecond
+
+	done := make(chan struct{})
+	go func() {
+		client.tokens.refreshInBackground(&client)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("FAILED: expected the background refresh to give up\n")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	token, err := GetClientToken(ctx, &client)
+	if err != nil {
+		t.Fatalf("Expected error FAILED: expected %v, got %v\n", nil, err)
+	}
+	if token != "Bearer token2" {
+		t.Errorf("FAILED: expected %v, got %v\n", "Bearer token2", token)
+	}
+}
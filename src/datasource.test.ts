@@ -1,7 +1,7 @@
 import { DataQueryRequest, DataSourceInstanceSettings, FieldType, MutableDataFrame } from '@grafana/data';
 import { SdsDataSourceOptions, SdsDataSourceType, SdsQuery } from 'types';
 import { DataSource } from 'datasource';
-import { BackendSrvRequest, FetchResponse } from '@grafana/runtime';
+import { DataSourceWithBackend } from '@grafana/runtime';
 import { Observable } from 'rxjs';
 
 jest.mock('@grafana/runtime', () => {
@@ -48,106 +48,32 @@ describe('DataSource', () => {
     },
     readOnly: false,
   };
-  const backendSrv = {
-    fetch(options: BackendSrvRequest): Observable<FetchResponse<unknown>> {
-      const edsResponse = {
-        data: [
-          {
-            TimeStamp: '2020-01-01',
-            Boolean: true,
-            Number: 1,
-            String: 'A',
-          },
-        ],
-      } as FetchResponse;
-
-      return new Observable((subscriber) => {
-        subscriber.next(edsResponse);
-      });
-    },
-  };
-
   describe('constructor', () => {
     it('should use passed in data source information', () => {
-      const datasource = new DataSource(adhSettings, backendSrv as any);
+      const datasource = new DataSource(adhSettings);
       expect(datasource.type).toEqual(SdsDataSourceType.ADH);
       expect(datasource.edsPort).toEqual(edsPort);
     });
   });
 
-  describe('queryEDS', () => {
-    it('should query with the expected parameters', (done) => {
-      const options = {
-        range: {
-          from: {
-            utc: () => ({
-              format: () => 'FROM',
-            }),
-          },
-          to: {
-            utc: () => ({
-              format: () => 'TO',
-            }),
-          },
-        },
-        targets: [
-          {
-            refId: 'REFID',
-            name: 'STREAM',
-            querytext: 'QUERYTEXT',
-            collection: 'COLLECTION',
-            id: 'ID',
-          },
-        ],
-      } as unknown as DataQueryRequest<SdsQuery>;
-
-      const datasource = new DataSource(adhSettings, backendSrv as any);
+  describe('query', () => {
+    it('should run EDS queries through the backend', () => {
+      const edsSettings = { ...adhSettings, jsonData: { ...adhSettings.jsonData, type: SdsDataSourceType.EDS } };
+      const request = { targets: [] } as unknown as DataQueryRequest<SdsQuery>;
+      const response = new Observable();
+      const backendQuery = jest.spyOn(DataSourceWithBackend.prototype, 'query').mockReturnValue(response as any);
 
-      const results = datasource.queryEDS(options);
+      const datasource = new DataSource(edsSettings);
 
-      results.subscribe({
-        next(result) {
-          expect(JSON.stringify(result)).toEqual(
-            JSON.stringify({
-              data: [
-                new MutableDataFrame({
-                  refId: 'REFID',
-                  name: 'STREAM',
-                  fields: [
-                    {
-                      name: 'TimeStamp',
-                      type: FieldType.time,
-                      values: [Date.parse('2020-01-01')],
-                    },
-                    {
-                      name: 'Boolean',
-                      type: FieldType.number,
-                      values: [1],
-                    },
-                    {
-                      name: 'Number',
-                      type: FieldType.number,
-                      values: [1],
-                    },
-                    {
-                      name: 'String',
-                      type: FieldType.string,
-                      values: ['A'],
-                    },
-                  ],
-                }),
-              ],
-            })
-          );
-          done();
-        },
-      });
+      expect(datasource.query(request)).toBe(response);
+      expect(backendQuery).toHaveBeenCalledWith(request);
+      backendQuery.mockRestore();
     });
   });
 
   describe('getStreams', () => {
     it('should query for streams', (done) => {
-      const datasource = new DataSource(adhSettings, backendSrv as any);
+      const datasource = new DataSource(adhSettings);
 
       datasource.query = jest.fn(() => {
         return new Observable((subscriber) => {